@@ -2,7 +2,11 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
@@ -10,6 +14,8 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// CreateDevice creates a device and answers with its location. Clients may choose the ID
+// of the device; otherwise the server assigns one.
 func (s *Server) CreateDevice(w http.ResponseWriter, r *http.Request) {
 	var req CreateDeviceRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -17,8 +23,9 @@ func (s *Server) CreateDevice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the ID is optional; the service assigns one if it is missing
 	errs := make([]string, 0)
-	if !helper.IsValidUUID(req.ID) {
+	if req.ID != "" && !helper.IsValidUUID(req.ID) {
 		errs = append(errs, "Invalid Device ID. UUID format expected")
 	}
 
@@ -28,10 +35,17 @@ func (s *Server) CreateDevice(w http.ResponseWriter, r *http.Request) {
 	}
 
 	newDevice := domain.Device{
-		ID:        req.ID,
-		Algorithm: req.Algorithm,
-		Label:     req.Label,
-		CreatedAt: time.Now(),
+		ID:               req.ID,
+		Algorithm:        req.Algorithm,
+		Label:            req.Label,
+		Metadata:         req.Metadata,
+		KeySize:          req.KeySize,
+		Curve:            req.Curve,
+		SignatureScheme:  req.SignatureScheme,
+		PSSSaltLength:    req.PSSSaltLength,
+		HashAlgorithm:    req.HashAlgorithm,
+		SignedDataFormat: req.SignedDataFormat,
+		CreatedAt:        time.Now(),
 	}
 
 	err := s.deviceService.CreateDevice(&newDevice)
@@ -39,7 +53,9 @@ func (s *Server) CreateDevice(w http.ResponseWriter, r *http.Request) {
 		switch err {
 		case domain.ErrDeviceAlreadyExists:
 			WriteErrorResponse(w, http.StatusConflict, []string{err.Error()})
-		case domain.ErrInvalidAlgorithm, domain.ErrInvalidDeviceID:
+		case domain.ErrInvalidAlgorithm, domain.ErrInvalidKeyParameters, domain.ErrInvalidSignatureScheme,
+			domain.ErrInvalidSaltLength, domain.ErrInvalidDeviceID, domain.ErrInvalidLabel, domain.ErrInvalidMetadata,
+			domain.ErrInvalidSignedDataFormat, domain.ErrInvalidHashAlgorithm:
 			WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
 		default:
 			WriteErrorResponse(w, http.StatusInternalServerError, []string{err.Error()})
@@ -47,6 +63,8 @@ func (s *Server) CreateDevice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("Location", "/api/v0/devices/"+newDevice.ID)
+	w.Header().Set("ETag", deviceETag(&newDevice))
 	WriteAPIResponse(w, http.StatusCreated, newDevice)
 }
 
@@ -73,12 +91,106 @@ func (s *Server) SignTransaction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := s.deviceService.SignTransaction(deviceId, req.Data)
+	// a retry carrying the same Idempotency-Key is answered with the original signature
+	idempotencyKey := r.Header.Get(IdempotencyKeyHeader)
+
+	result, err := s.deviceService.SignTransaction(deviceId, req.Data, idempotencyKey)
 	if err != nil {
 		switch err {
 		case domain.ErrDeviceNotFound:
 			WriteErrorResponse(w, http.StatusNotFound, []string{err.Error()})
-		case domain.ErrInvalidDeviceID:
+		case domain.ErrDeviceNotActive:
+			WriteErrorResponse(w, http.StatusLocked, []string{err.Error()})
+		case domain.ErrIdempotencyKeyReused:
+			WriteErrorResponse(w, http.StatusUnprocessableEntity, []string{err.Error()})
+		case domain.ErrInvalidDeviceID, domain.ErrReservedData, domain.ErrInvalidIdempotencyKey:
+			WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+		default:
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{err.Error()})
+		}
+		return
+	}
+
+	WriteAPIResponse(w, http.StatusOK, result)
+}
+
+// SignTransactionBatch signs an ordered list of data items as one contiguous segment of the
+// device chain. Either all items are signed or none is.
+func (s *Server) SignTransactionBatch(w http.ResponseWriter, r *http.Request) {
+	deviceId := mux.Vars(r)["deviceId"]
+	if !helper.IsValidUUID(deviceId) {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{"Invalid Device ID. UUID format expected"})
+		return
+	}
+
+	var req SignTransactionBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{"Invalid JSON"})
+		return
+	}
+
+	errs := make([]string, 0)
+	for i, data := range req.Data {
+		if data == "" {
+			errs = append(errs, fmt.Sprintf("data[%d]: %s", i, domain.ErrEmptyData.Error()))
+		}
+	}
+
+	if len(errs) > 0 {
+		WriteErrorResponse(w, http.StatusBadRequest, errs)
+		return
+	}
+
+	results, err := s.deviceService.SignTransactions(deviceId, req.Data)
+	if err != nil {
+		switch err {
+		case domain.ErrDeviceNotFound:
+			WriteErrorResponse(w, http.StatusNotFound, []string{err.Error()})
+		case domain.ErrDeviceNotActive:
+			WriteErrorResponse(w, http.StatusLocked, []string{err.Error()})
+		case domain.ErrEmptyBatch, domain.ErrBatchTooLarge, domain.ErrEmptyData, domain.ErrReservedData:
+			WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+		default:
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{err.Error()})
+		}
+		return
+	}
+
+	WriteAPIResponse(w, http.StatusOK, results)
+}
+
+func (s *Server) VerifySignature(w http.ResponseWriter, r *http.Request) {
+	deviceId := mux.Vars(r)["deviceId"]
+	if !helper.IsValidUUID(deviceId) {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{"Invalid Device ID. UUID format expected"})
+		return
+	}
+
+	var req VerifySignatureRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{"Invalid JSON"})
+		return
+	}
+
+	errs := make([]string, 0)
+	if req.SignedData == "" {
+		errs = append(errs, domain.ErrEmptySignedData.Error())
+	}
+	if req.Signature == "" {
+		errs = append(errs, domain.ErrEmptySignature.Error())
+	}
+
+	if len(errs) > 0 {
+		WriteErrorResponse(w, http.StatusBadRequest, errs)
+		return
+	}
+
+	result, err := s.deviceService.VerifySignature(deviceId, req.SignedData, req.Signature)
+	if err != nil {
+		switch err {
+		case domain.ErrDeviceNotFound:
+			WriteErrorResponse(w, http.StatusNotFound, []string{err.Error()})
+		case domain.ErrInvalidDeviceID, domain.ErrInvalidSignatureEncoding:
 			WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
 		default:
 			WriteErrorResponse(w, http.StatusInternalServerError, []string{err.Error()})
@@ -109,15 +221,89 @@ func (s *Server) GetDevice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("ETag", deviceETag(device))
 	WriteAPIResponse(w, http.StatusOK, device)
 }
 
+// GetAllDevices lists devices page by page. Query parameters:
+//
+//	limit          page size, 1 to 500 (default 50)
+//	cursor         nextCursor of the previous page
+//	algorithm      only devices of this algorithm
+//	label          only devices whose label contains this text
+//	createdAfter   only devices created at or after this RFC 3339 time
+//	createdBefore  only devices created before this RFC 3339 time
+//	metadata.<key> only devices with this metadata value, for instance metadata.store=berlin
+//	sort           createdAt (default) or signatureCounter, prefixed with - for descending order
 func (s *Server) GetAllDevices(w http.ResponseWriter, r *http.Request) {
-	devices, err := s.deviceService.FindAll()
+	query, errs := parseDeviceQuery(r.URL.Query())
+	if len(errs) > 0 {
+		WriteErrorResponse(w, http.StatusBadRequest, errs)
+		return
+	}
+
+	page, err := s.deviceService.ListDevices(query)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusInternalServerError, []string{err.Error()})
+		switch err {
+		case domain.ErrInvalidCursor, domain.ErrInvalidPageLimit, domain.ErrInvalidSortField, domain.ErrInvalidDateRange:
+			WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+		default:
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{err.Error()})
+		}
 		return
 	}
 
-	WriteAPIResponse(w, http.StatusOK, devices)
+	WriteAPIPage(w, http.StatusOK, page.Devices, page.NextCursor)
+}
+
+func parseDeviceQuery(values url.Values) (domain.DeviceQuery, []string) {
+	query := domain.DeviceQuery{
+		Algorithm:     values.Get("algorithm"),
+		LabelContains: values.Get("label"),
+		Cursor:        values.Get("cursor"),
+	}
+	errs := make([]string, 0)
+
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			errs = append(errs, domain.ErrInvalidPageLimit.Error())
+		}
+		query.Limit = n
+	}
+
+	for _, param := range []struct {
+		name   string
+		target **time.Time
+	}{
+		{name: "createdAfter", target: &query.CreatedAfter},
+		{name: "createdBefore", target: &query.CreatedBefore},
+	} {
+		value := values.Get(param.name)
+		if value == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("Invalid %s. RFC 3339 time expected", param.name))
+			continue
+		}
+		*param.target = &t
+	}
+
+	for name := range values {
+		if key := strings.TrimPrefix(name, "metadata."); key != name && key != "" {
+			if query.Metadata == nil {
+				query.Metadata = make(map[string]string)
+			}
+			query.Metadata[key] = values.Get(name)
+		}
+	}
+
+	if sortBy := values.Get("sort"); sortBy != "" {
+		query.SortBy, query.Descending = strings.TrimPrefix(sortBy, "-"), strings.HasPrefix(sortBy, "-")
+	}
+
+	return query, errs
 }
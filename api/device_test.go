@@ -2,9 +2,12 @@ package api_test
 
 import (
 	"bytes"
+	encoding "encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/fiskaly/coding-challenges/signing-service-challenge/api"
@@ -17,13 +20,28 @@ import (
 
 func setupTestServer() *mux.Router {
 	repo := persistence.NewInMemoryRepository()
-	svc := service.NewDeviceService(repo)
+	transactions := persistence.NewInMemoryTransactionRepository()
+	svc := service.NewDeviceService(repo, transactions)
 	srv := api.NewServer("", svc)
 	router := mux.NewRouter()
 	router.HandleFunc("/api/v0/devices/{deviceId}/sign", srv.SignTransaction).Methods(http.MethodPost)
+	router.HandleFunc("/api/v0/devices/{deviceId}/sign/batch", srv.SignTransactionBatch).Methods(http.MethodPost)
+	router.HandleFunc("/api/v0/devices/{deviceId}/sign/digest", srv.SignDigest).Methods(http.MethodPost)
+	router.HandleFunc("/api/v0/devices/{deviceId}/sign/upload", srv.SignUpload).Methods(http.MethodPost)
+	router.HandleFunc("/api/v0/devices/{deviceId}/verify", srv.VerifySignature).Methods(http.MethodPost)
 	router.HandleFunc("/api/v0/devices", srv.CreateDevice).Methods(http.MethodPost)
 	router.HandleFunc("/api/v0/devices/{deviceId}", srv.GetDevice).Methods(http.MethodGet)
+	router.HandleFunc("/api/v0/devices/{deviceId}", srv.UpdateDevice).Methods(http.MethodPatch)
+	router.HandleFunc("/api/v0/devices/{deviceId}/public-key", srv.GetPublicKey).Methods(http.MethodGet)
+	router.HandleFunc("/api/v0/.well-known/jwks.json", srv.GetJWKS).Methods(http.MethodGet)
 	router.HandleFunc("/api/v0/devices", srv.GetAllDevices).Methods(http.MethodGet)
+	router.HandleFunc("/api/v0/devices/{deviceId}/transactions", srv.ListTransactions).Methods(http.MethodGet)
+	router.HandleFunc("/api/v0/devices/{deviceId}/transactions/{counter}", srv.GetTransaction).Methods(http.MethodGet)
+	router.HandleFunc("/api/v0/devices/{deviceId}/audit", srv.AuditDevice).Methods(http.MethodGet)
+	router.HandleFunc("/api/v0/devices/{deviceId}/deactivate", srv.DeactivateDevice).Methods(http.MethodPost)
+	router.HandleFunc("/api/v0/devices/{deviceId}/reactivate", srv.ReactivateDevice).Methods(http.MethodPost)
+	router.HandleFunc("/api/v0/devices/{deviceId}/decommission", srv.DecommissionDevice).Methods(http.MethodPost)
+	router.HandleFunc("/api/v0/devices/{deviceId}/rotate-key", srv.RotateKey).Methods(http.MethodPost)
 	return router
 }
 
@@ -51,6 +69,32 @@ func TestServer_CreateDevice(t *testing.T) {
 
 	})
 
+	t.Run("success to register an ED25519 device", func(t *testing.T) {
+		id := uuid.New().String()
+		json := []byte(`{
+			"id": "` + id + `",
+			"algorithm": "ED25519",
+			"label": "Edge Device"
+		}`)
+		req, err := http.NewRequest("POST", "/api/v0/devices", bytes.NewReader(json))
+		assert.NoError(t, err)
+
+		router := setupTestServer()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		req, err = http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/sign", id), bytes.NewReader([]byte(`{"data": "COFFEE:20251026"}`)))
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
 	t.Run("invalid algorithm", func(t *testing.T) {
 		id := uuid.New().String()
 		json := []byte(`{
@@ -73,7 +117,87 @@ func TestServer_CreateDevice(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, statusCode)
 	})
 
-	t.Run("empty deviceId", func(t *testing.T) {
+	t.Run("invalid key parameters", func(t *testing.T) {
+		id := uuid.New().String()
+		json := []byte(`{
+			"id": "` + id + `",
+			"algorithm": "ECC",
+			"curve": "P-192",
+			"label": "Device 1"
+		}`)
+		req, err := http.NewRequest("POST", "/api/v0/devices", bytes.NewReader(json))
+		assert.NoError(t, err)
+
+		router := setupTestServer()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("key parameters in device response", func(t *testing.T) {
+		id := uuid.New().String()
+		json := []byte(`{
+			"id": "` + id + `",
+			"algorithm": "ECC",
+			"curve": "P-256",
+			"label": "Device 1"
+		}`)
+		req, err := http.NewRequest("POST", "/api/v0/devices", bytes.NewReader(json))
+		assert.NoError(t, err)
+
+		router := setupTestServer()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"curve": "P-256"`)
+	})
+
+	t.Run("signature scheme in device response", func(t *testing.T) {
+		id := uuid.New().String()
+		json := []byte(`{
+			"id": "` + id + `",
+			"algorithm": "RSA",
+			"signatureScheme": "PSS",
+			"pssSaltLength": 32,
+			"label": "Device 1"
+		}`)
+		req, err := http.NewRequest("POST", "/api/v0/devices", bytes.NewReader(json))
+		assert.NoError(t, err)
+
+		router := setupTestServer()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"signatureScheme": "PSS"`)
+		assert.Contains(t, rr.Body.String(), `"pssSaltLength": 32`)
+	})
+
+	t.Run("invalid signature scheme", func(t *testing.T) {
+		id := uuid.New().String()
+		json := []byte(`{
+			"id": "` + id + `",
+			"algorithm": "ECC",
+			"signatureScheme": "PSS",
+			"label": "Device 1"
+		}`)
+		req, err := http.NewRequest("POST", "/api/v0/devices", bytes.NewReader(json))
+		assert.NoError(t, err)
+
+		router := setupTestServer()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("empty deviceId is assigned by the server", func(t *testing.T) {
 		json := []byte(`{
 			"algorithm": "RSA",
 			"label": "Device 1"
@@ -89,7 +213,45 @@ func TestServer_CreateDevice(t *testing.T) {
 
 		statusCode := rr.Code
 
-		assert.Equal(t, http.StatusBadRequest, statusCode)
+		assert.Equal(t, http.StatusCreated, statusCode)
+
+		var response struct {
+			Data struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		}
+		assert.NoError(t, encoding.Unmarshal(rr.Body.Bytes(), &response))
+
+		id, err := uuid.Parse(response.Data.ID)
+		assert.NoError(t, err, "assigned ID should be a UUID")
+		assert.Equal(t, uuid.Version(7), id.Version())
+		assert.Equal(t, "/api/v0/devices/"+response.Data.ID, rr.Header().Get("Location"))
+
+		req, err = http.NewRequest("GET", rr.Header().Get("Location"), nil)
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("invalid deviceId", func(t *testing.T) {
+		json := []byte(`{
+			"id": "device-1",
+			"algorithm": "RSA",
+			"label": "Device 1"
+		}`)
+		req, err := http.NewRequest("POST", "/api/v0/devices", bytes.NewReader(json))
+		assert.NoError(t, err)
+
+		router := setupTestServer()
+
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
 	})
 
 	t.Run("duplicate deviceId", func(t *testing.T) {
@@ -112,6 +274,7 @@ func TestServer_CreateDevice(t *testing.T) {
 		statusCode := rr.Code
 
 		assert.Equal(t, http.StatusCreated, statusCode)
+		assert.Equal(t, "/api/v0/devices/"+id, rr.Header().Get("Location"))
 
 		req, err = http.NewRequest("POST", "/api/v0/devices", bytes.NewReader(json))
 		assert.NoError(t, err)
@@ -154,6 +317,20 @@ func TestServer_SignTransaction(t *testing.T) {
 		statusCode = rr.Code
 
 		assert.Equal(t, http.StatusOK, statusCode)
+
+		var response struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		assert.NoError(t, encoding.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, id, response.Data["deviceId"])
+		assert.Equal(t, float64(0), response.Data["counter"])
+		assert.Equal(t, "RSA", response.Data["algorithm"])
+		assert.Equal(t, "PKCS1v15", response.Data["signatureScheme"])
+		assert.Equal(t, "SHA-256", response.Data["hashAlgorithm"])
+		assert.Equal(t, float64(1), response.Data["keyVersion"])
+		for _, field := range []string{"timestamp", "previousSignature", "signature", "signedData"} {
+			assert.NotEmpty(t, response.Data[field], field)
+		}
 	})
 	t.Run("failed to sign a transaction, device not found", func(t *testing.T) {
 		router := setupTestServer()
@@ -184,6 +361,44 @@ func TestServer_SignTransaction(t *testing.T) {
 
 		assert.NotEqual(t, http.StatusOK, statusCode)
 	})
+	t.Run("retry with the same idempotency key", func(t *testing.T) {
+		router := setupTestServer()
+
+		id := uuid.New().String()
+		json := []byte(`{
+			"id": "` + id + `",
+			"algorithm": "ECC",
+			"label": "Device 1"
+		}`)
+		req, err := http.NewRequest("POST", "/api/v0/devices", bytes.NewReader(json))
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		sign := func(data string) *httptest.ResponseRecorder {
+			req, err := http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/sign", id), bytes.NewReader([]byte(`{"data": "`+data+`"}`)))
+			assert.NoError(t, err)
+			req.Header.Set("Idempotency-Key", "order-1")
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			return rr
+		}
+
+		first := sign("COFFEE:20251026")
+		assert.Equal(t, http.StatusOK, first.Code)
+
+		retry := sign("COFFEE:20251026")
+		assert.Equal(t, http.StatusOK, retry.Code)
+		assert.Equal(t, first.Body.String(), retry.Body.String())
+		assert.Contains(t, retry.Body.String(), `"counter": 0`)
+
+		conflict := sign("TEA:20251026")
+		assert.Equal(t, http.StatusUnprocessableEntity, conflict.Code)
+	})
 	t.Run("failed to sign a transaction, missing deviceId", func(t *testing.T) {
 		router := setupTestServer()
 
@@ -199,6 +414,92 @@ func TestServer_SignTransaction(t *testing.T) {
 	})
 }
 
+func TestServer_SignTransactionBatch(t *testing.T) {
+	t.Run("sign a batch of transactions", func(t *testing.T) {
+		router := setupTestServer()
+
+		id := uuid.New().String()
+		json := []byte(`{
+			"id": "` + id + `",
+			"algorithm": "ECC",
+			"label": "Device 1"
+		}`)
+		req, err := http.NewRequest("POST", "/api/v0/devices", bytes.NewReader(json))
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		req, err = http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/sign/batch", id), bytes.NewReader([]byte(`{"data": ["COFFEE", "TEA", "CAKE"]}`)))
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response struct {
+			Data []struct {
+				Counter    int    `json:"counter"`
+				SignedData string `json:"signedData"`
+			} `json:"data"`
+		}
+		assert.NoError(t, encoding.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, 3, len(response.Data))
+		for i, result := range response.Data {
+			assert.Equal(t, i, result.Counter)
+		}
+		assert.True(t, strings.HasPrefix(response.Data[1].SignedData, "v1:1:1,"), "new devices sign in the V1 format")
+		assert.Contains(t, response.Data[1].SignedData, ",3:TEA,")
+	})
+
+	t.Run("reject batch with an empty item", func(t *testing.T) {
+		router := setupTestServer()
+
+		id := uuid.New().String()
+		json := []byte(`{
+			"id": "` + id + `",
+			"algorithm": "ECC",
+			"label": "Device 1"
+		}`)
+		req, err := http.NewRequest("POST", "/api/v0/devices", bytes.NewReader(json))
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		req, err = http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/sign/batch", id), bytes.NewReader([]byte(`{"data": ["COFFEE", ""]}`)))
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "data[1]")
+
+		req, err = http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/sign/batch", id), bytes.NewReader([]byte(`{"data": []}`)))
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		req, err = http.NewRequest("GET", fmt.Sprintf("/api/v0/devices/%s/transactions", id), nil)
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotContains(t, rr.Body.String(), "COFFEE", "rejected batch should not be recorded")
+	})
+}
+
 func TestServer_GetDevice(t *testing.T) {
 	t.Run("success to get a device", func(t *testing.T) {
 		router := setupTestServer()
@@ -274,4 +575,469 @@ func TestServer_GetAllDevices(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, statusCode)
 	})
+
+	t.Run("pages through devices with a cursor", func(t *testing.T) {
+		router := setupTestServer()
+
+		for i := 0; i < 3; i++ {
+			json := []byte(`{"id": "` + uuid.New().String() + `", "algorithm": "ECC", "label": "Register ` + strconv.Itoa(i) + `"}`)
+			req, err := http.NewRequest("POST", "/api/v0/devices", bytes.NewReader(json))
+			assert.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusCreated, rr.Code)
+		}
+
+		req, err := http.NewRequest("GET", "/api/v0/devices?limit=2&sort=-createdAt", nil)
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var page struct {
+			Data       []map[string]interface{} `json:"data"`
+			NextCursor string                   `json:"nextCursor"`
+		}
+		assert.NoError(t, encoding.Unmarshal(rr.Body.Bytes(), &page))
+		assert.Len(t, page.Data, 2)
+		assert.NotEmpty(t, page.NextCursor)
+
+		req, err = http.NewRequest("GET", "/api/v0/devices?limit=2&sort=-createdAt&cursor="+page.NextCursor, nil)
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		page.NextCursor = ""
+		assert.NoError(t, encoding.Unmarshal(rr.Body.Bytes(), &page))
+		assert.Len(t, page.Data, 1)
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("rejects an invalid query", func(t *testing.T) {
+		router := setupTestServer()
+
+		for _, query := range []string{"limit=0", "limit=abc", "cursor=garbage", "sort=label", "createdAfter=yesterday"} {
+			req, err := http.NewRequest("GET", "/api/v0/devices?"+query, nil)
+			assert.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+	})
+}
+
+func TestServer_Transactions(t *testing.T) {
+	t.Run("success to get the signature history", func(t *testing.T) {
+		router := setupTestServer()
+
+		id := uuid.New().String()
+		json := []byte(`{
+			"id": "` + id + `",
+			"algorithm": "ECC",
+			"label": "Device 1"
+		}`)
+		req, err := http.NewRequest("POST", "/api/v0/devices", bytes.NewReader(json))
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		req, err = http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/sign", id), bytes.NewReader([]byte(`{"data": "COFFEE:20251026"}`)))
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		req, err = http.NewRequest("GET", fmt.Sprintf("/api/v0/devices/%s/transactions", id), nil)
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), "COFFEE:20251026")
+
+		req, err = http.NewRequest("GET", fmt.Sprintf("/api/v0/devices/%s/transactions/0", id), nil)
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		req, err = http.NewRequest("GET", fmt.Sprintf("/api/v0/devices/%s/transactions/1", id), nil)
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("invalid counter", func(t *testing.T) {
+		router := setupTestServer()
+
+		req, err := http.NewRequest("GET", fmt.Sprintf("/api/v0/devices/%s/transactions/abc", uuid.New().String()), nil)
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("device not found", func(t *testing.T) {
+		router := setupTestServer()
+
+		req, err := http.NewRequest("GET", fmt.Sprintf("/api/v0/devices/%s/transactions", uuid.New().String()), nil)
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestServer_VerifySignature(t *testing.T) {
+	t.Run("success to verify a signature", func(t *testing.T) {
+		router := setupTestServer()
+
+		id := uuid.New().String()
+		json := []byte(`{
+			"id": "` + id + `",
+			"algorithm": "ECC",
+			"label": "Device 1"
+		}`)
+		req, err := http.NewRequest("POST", "/api/v0/devices", bytes.NewReader(json))
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		req, err = http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/sign", id), bytes.NewReader([]byte(`{"data": "COFFEE:20251026"}`)))
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var signResponse struct {
+			Data struct {
+				Signature  string `json:"signature"`
+				SignedData string `json:"signedData"`
+			} `json:"data"`
+		}
+		assert.NoError(t, encoding.Unmarshal(rr.Body.Bytes(), &signResponse))
+
+		body, err := encoding.Marshal(api.VerifySignatureRequest{
+			SignedData: signResponse.Data.SignedData,
+			Signature:  signResponse.Data.Signature,
+		})
+		assert.NoError(t, err)
+
+		req, err = http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/verify", id), bytes.NewReader(body))
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"valid": true`)
+	})
+
+	t.Run("verification decomposes V1 signed data", func(t *testing.T) {
+		router := setupTestServer()
+
+		id := uuid.New().String()
+		json := []byte(`{
+			"id": "` + id + `",
+			"algorithm": "ECC",
+			"signedDataFormat": "V1"
+		}`)
+		req, err := http.NewRequest("POST", "/api/v0/devices", bytes.NewReader(json))
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"signedDataFormat": "V1"`)
+
+		req, err = http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/sign", id), bytes.NewReader([]byte(`{"data": "COFFEE_20251026"}`)))
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var signResponse struct {
+			Data struct {
+				Signature  string `json:"signature"`
+				SignedData string `json:"signedData"`
+			} `json:"data"`
+		}
+		assert.NoError(t, encoding.Unmarshal(rr.Body.Bytes(), &signResponse))
+
+		body, err := encoding.Marshal(api.VerifySignatureRequest{
+			SignedData: signResponse.Data.SignedData,
+			Signature:  signResponse.Data.Signature,
+		})
+		assert.NoError(t, err)
+
+		req, err = http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/verify", id), bytes.NewReader(body))
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var verifyResponse struct {
+			Data struct {
+				Valid bool `json:"valid"`
+				Entry struct {
+					Format   string `json:"format"`
+					Counter  int    `json:"counter"`
+					DeviceID string `json:"deviceId"`
+					Data     string `json:"data"`
+				} `json:"entry"`
+			} `json:"data"`
+		}
+		assert.NoError(t, encoding.Unmarshal(rr.Body.Bytes(), &verifyResponse))
+		assert.True(t, verifyResponse.Data.Valid)
+		assert.Equal(t, "V1", verifyResponse.Data.Entry.Format)
+		assert.Equal(t, 0, verifyResponse.Data.Entry.Counter)
+		assert.Equal(t, id, verifyResponse.Data.Entry.DeviceID)
+		assert.Equal(t, "COFFEE_20251026", verifyResponse.Data.Entry.Data)
+	})
+
+	t.Run("missing signature", func(t *testing.T) {
+		router := setupTestServer()
+
+		req, err := http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/verify", uuid.New().String()), bytes.NewReader([]byte(`{"signedData": "0_COFFEE_MQ"}`)))
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("device not found", func(t *testing.T) {
+		router := setupTestServer()
+
+		req, err := http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/verify", uuid.New().String()), bytes.NewReader([]byte(`{"signedData": "0_COFFEE_MQ", "signature": "c2lnbmF0dXJl"}`)))
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestServer_AuditDevice(t *testing.T) {
+	t.Run("success to audit a device", func(t *testing.T) {
+		router := setupTestServer()
+
+		id := uuid.New().String()
+		json := []byte(`{
+			"id": "` + id + `",
+			"algorithm": "RSA",
+			"label": "Device 1"
+		}`)
+		req, err := http.NewRequest("POST", "/api/v0/devices", bytes.NewReader(json))
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		req, err = http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/sign", id), bytes.NewReader([]byte(`{"data": "COFFEE:20251026"}`)))
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		req, err = http.NewRequest("GET", fmt.Sprintf("/api/v0/devices/%s/audit", id), nil)
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"valid": true`)
+	})
+
+	t.Run("device not found", func(t *testing.T) {
+		router := setupTestServer()
+
+		req, err := http.NewRequest("GET", fmt.Sprintf("/api/v0/devices/%s/audit", uuid.New().String()), nil)
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestServer_DeviceLifecycle(t *testing.T) {
+	t.Run("disabled device cannot sign", func(t *testing.T) {
+		router := setupTestServer()
+
+		id := uuid.New().String()
+		json := []byte(`{
+			"id": "` + id + `",
+			"algorithm": "ECC",
+			"label": "Device 1"
+		}`)
+		req, err := http.NewRequest("POST", "/api/v0/devices", bytes.NewReader(json))
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"status": "ACTIVE"`)
+
+		req, err = http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/deactivate", id), bytes.NewReader([]byte(`{"reason": "stolen"}`)))
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"status": "DISABLED"`)
+		assert.Contains(t, rr.Body.String(), `"reason": "stolen"`)
+
+		req, err = http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/sign", id), bytes.NewReader([]byte(`{"data": "COFFEE:20251026"}`)))
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusLocked, rr.Code)
+
+		// the reason is optional
+		req, err = http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/reactivate", id), http.NoBody)
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		req, err = http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/reactivate", id), http.NoBody)
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("decommission unknown device", func(t *testing.T) {
+		router := setupTestServer()
+
+		req, err := http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/decommission", uuid.New().String()), http.NoBody)
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestServer_RotateKey(t *testing.T) {
+	t.Run("rotate key and verify old signature", func(t *testing.T) {
+		router := setupTestServer()
+
+		id := uuid.New().String()
+		json := []byte(`{
+			"id": "` + id + `",
+			"algorithm": "ECC",
+			"label": "Device 1"
+		}`)
+		req, err := http.NewRequest("POST", "/api/v0/devices", bytes.NewReader(json))
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"keyVersion": 1`)
+
+		req, err = http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/sign", id), bytes.NewReader([]byte(`{"data": "COFFEE:20251026"}`)))
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var signResponse struct {
+			Data struct {
+				Signature  string `json:"signature"`
+				SignedData string `json:"signedData"`
+			} `json:"data"`
+		}
+		assert.NoError(t, encoding.Unmarshal(rr.Body.Bytes(), &signResponse))
+
+		req, err = http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/rotate-key", id), http.NoBody)
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"keyVersion": 2`)
+		assert.Contains(t, rr.Body.String(), `"validUntilCounter": 1`)
+		assert.Contains(t, rr.Body.String(), `"validFromCounter": 2`)
+
+		verify, err := encoding.Marshal(map[string]string{
+			"signedData": signResponse.Data.SignedData,
+			"signature":  signResponse.Data.Signature,
+		})
+		assert.NoError(t, err)
+
+		req, err = http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/verify", id), bytes.NewReader(verify))
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"valid": true`)
+	})
+
+	t.Run("rotate key of unknown device", func(t *testing.T) {
+		router := setupTestServer()
+
+		req, err := http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/rotate-key", uuid.New().String()), http.NoBody)
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
 }
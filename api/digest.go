@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/helper"
+	"github.com/gorilla/mux"
+)
+
+// SignDigest signs a hex or base64 digest of data the client does not upload. The digest is
+// embedded into the device chain as DIGEST:<hash algorithm>:<hex digest>.
+func (s *Server) SignDigest(w http.ResponseWriter, r *http.Request) {
+	deviceId := mux.Vars(r)["deviceId"]
+	if !helper.IsValidUUID(deviceId) {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{"Invalid Device ID. UUID format expected"})
+		return
+	}
+
+	var req SignDigestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{"Invalid JSON"})
+		return
+	}
+
+	idempotencyKey := r.Header.Get(IdempotencyKeyHeader)
+
+	result, err := s.deviceService.SignDigest(deviceId, req.HashAlgorithm, req.Digest, idempotencyKey)
+	if err != nil {
+		switch err {
+		case domain.ErrDeviceNotFound:
+			WriteErrorResponse(w, http.StatusNotFound, []string{err.Error()})
+		case domain.ErrDeviceNotActive:
+			WriteErrorResponse(w, http.StatusLocked, []string{err.Error()})
+		case domain.ErrIdempotencyKeyReused:
+			WriteErrorResponse(w, http.StatusUnprocessableEntity, []string{err.Error()})
+		case domain.ErrInvalidDigestAlgorithm, domain.ErrInvalidDigest, domain.ErrInvalidIdempotencyKey:
+			WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+		default:
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{err.Error()})
+		}
+		return
+	}
+
+	WriteAPIResponse(w, http.StatusOK, result)
+}
@@ -0,0 +1,70 @@
+package api_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_SignDigest(t *testing.T) {
+	router := setupTestServer()
+
+	id := uuid.New().String()
+	json := []byte(`{
+		"id": "` + id + `",
+		"algorithm": "ECC"
+	}`)
+	req, err := http.NewRequest("POST", "/api/v0/devices", bytes.NewReader(json))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	sum := sha256.Sum256([]byte("a large document"))
+
+	signDigest := func(body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/sign/digest", id), bytes.NewReader([]byte(body)))
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("signs a digest", func(t *testing.T) {
+		rr := signDigest(`{"hashAlgorithm": "SHA-256", "digest": "` + hex.EncodeToString(sum[:]) + `"}`)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), "DIGEST:SHA-256:"+hex.EncodeToString(sum[:]))
+	})
+
+	t.Run("invalid digests", func(t *testing.T) {
+		for _, body := range []string{
+			`{"hashAlgorithm": "MD5", "digest": "` + hex.EncodeToString(sum[:16]) + `"}`,
+			`{"hashAlgorithm": "SHA-512", "digest": "` + hex.EncodeToString(sum[:]) + `"}`,
+			`{"hashAlgorithm": "SHA-256"}`,
+			`not json`,
+		} {
+			rr := signDigest(body)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, body)
+		}
+	})
+
+	t.Run("digest data is reserved", func(t *testing.T) {
+		req, err := http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/sign", id), bytes.NewReader([]byte(`{"data": "DIGEST:SHA-256:00"}`)))
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -1,11 +1,43 @@
 package api
 
 type CreateDeviceRequest struct {
-	ID        string `json:"id"`
-	Algorithm string `json:"algorithm"`
-	Label     string `json:"label,omitempty"`
+	ID               string            `json:"id"`
+	Algorithm        string            `json:"algorithm"`
+	Label            string            `json:"label,omitempty"`
+	Metadata         map[string]string `json:"metadata,omitempty"`
+	KeySize          int               `json:"keySize,omitempty"`
+	Curve            string            `json:"curve,omitempty"`
+	SignatureScheme  string            `json:"signatureScheme,omitempty"`
+	PSSSaltLength    int               `json:"pssSaltLength,omitempty"`
+	HashAlgorithm    string            `json:"hashAlgorithm,omitempty"`
+	SignedDataFormat string            `json:"signedDataFormat,omitempty"`
 }
 
 type SignTransactionRequest struct {
 	Data string `json:"data"`
 }
+
+type SignTransactionBatchRequest struct {
+	Data []string `json:"data"`
+}
+
+type VerifySignatureRequest struct {
+	SignedData string `json:"signedData"`
+	Signature  string `json:"signature"`
+}
+
+type ChangeDeviceStatusRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// UpdateDeviceRequest is a JSON merge patch of a device: omitted attributes are kept and
+// metadata keys set to null are removed.
+type UpdateDeviceRequest struct {
+	Label    *string            `json:"label,omitempty"`
+	Metadata map[string]*string `json:"metadata,omitempty"`
+}
+
+type SignDigestRequest struct {
+	HashAlgorithm string `json:"hashAlgorithm"`
+	Digest        string `json:"digest"`
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+// JWKSMaxAge is how long, in seconds, clients may cache the key set before fetching it again.
+const JWKSMaxAge = 300
+
+// JWKSet is a JSON Web Key Set as defined by RFC 7517.
+type JWKSet struct {
+	Keys []*crypto.JWK `json:"keys"`
+}
+
+// GetJWKS publishes the public keys of every active device as a JWK set, so verifiers
+// can look keys up by kid. Keys retired by a rotation stay listed, as receipts signed
+// with them remain valid. The set is not wrapped in the API response container.
+func (s *Server) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	devices, err := s.deviceService.FindAll()
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, []string{err.Error()})
+		return
+	}
+
+	// a stable order keeps the ETag stable while the keys do not change
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].ID < devices[j].ID
+	})
+
+	set := JWKSet{Keys: make([]*crypto.JWK, 0)}
+	for _, device := range devices {
+		if device.CurrentStatus() != domain.DeviceStatusActive {
+			continue
+		}
+
+		for _, key := range device.Keys() {
+			jwk, err := deviceKeyJWK(device, key)
+			if err != nil {
+				WriteErrorResponse(w, http.StatusInternalServerError, []string{err.Error()})
+				return
+			}
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+
+	body, err := json.MarshalIndent(set, "", "  ")
+	if err != nil {
+		WriteInternalError(w)
+		return
+	}
+
+	digest := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(digest[:16]) + `"`
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", JWKSMaxAge))
+	w.Header().Set("ETag", etag)
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// etagMatches reports whether an If-None-Match header lists etag or is a wildcard.
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,82 @@
+package api_test
+
+import (
+	"bytes"
+	encoding "encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_GetJWKS(t *testing.T) {
+	router := setupTestServer()
+
+	ids := make([]string, 0)
+	for _, algorithm := range []string{"RSA", "ECC", "ED25519"} {
+		id := uuid.New().String()
+		json := []byte(`{
+			"id": "` + id + `",
+			"algorithm": "` + algorithm + `",
+			"label": "Device 1"
+		}`)
+		req, err := http.NewRequest("POST", "/api/v0/devices", bytes.NewReader(json))
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		ids = append(ids, id)
+	}
+
+	post := func(path string) {
+		req, err := http.NewRequest("POST", path, http.NoBody)
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	}
+
+	// the first device rotates its key, the last one stops being active
+	post(fmt.Sprintf("/api/v0/devices/%s/rotate-key", ids[0]))
+	post(fmt.Sprintf("/api/v0/devices/%s/deactivate", ids[2]))
+
+	getJWKS := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", "/api/v0/.well-known/jwks.json", nil)
+		assert.NoError(t, err)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := getJWKS("")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("Cache-Control"), "max-age=")
+	etag := rr.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	assert.NoError(t, encoding.Unmarshal(rr.Body.Bytes(), &set))
+
+	kids := make([]string, 0)
+	for _, key := range set.Keys {
+		kids = append(kids, key["kid"])
+	}
+	assert.ElementsMatch(t, []string{ids[0], ids[0] + ":2", ids[1]}, kids)
+
+	rr = getJWKS(etag)
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+	assert.Empty(t, rr.Body.String())
+}
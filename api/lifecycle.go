@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/helper"
+	"github.com/gorilla/mux"
+)
+
+// DeactivateDevice disables a device; it stops signing until it is reactivated.
+func (s *Server) DeactivateDevice(w http.ResponseWriter, r *http.Request) {
+	s.changeDeviceStatus(w, r, domain.DeviceStatusDisabled)
+}
+
+// ReactivateDevice lets a disabled device sign again.
+func (s *Server) ReactivateDevice(w http.ResponseWriter, r *http.Request) {
+	s.changeDeviceStatus(w, r, domain.DeviceStatusActive)
+}
+
+// DecommissionDevice retires a device for good and destroys its private key.
+func (s *Server) DecommissionDevice(w http.ResponseWriter, r *http.Request) {
+	s.changeDeviceStatus(w, r, domain.DeviceStatusDecommissioned)
+}
+
+func (s *Server) changeDeviceStatus(w http.ResponseWriter, r *http.Request, status string) {
+	deviceId := mux.Vars(r)["deviceId"]
+	if !helper.IsValidUUID(deviceId) {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{"Invalid Device ID. UUID format expected"})
+		return
+	}
+
+	// the reason is optional, and so is the body
+	var req ChangeDeviceStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{"Invalid JSON"})
+		return
+	}
+
+	device, err := s.deviceService.ChangeDeviceStatus(deviceId, status, req.Reason)
+	if err != nil {
+		switch err {
+		case domain.ErrDeviceNotFound:
+			WriteErrorResponse(w, http.StatusNotFound, []string{err.Error()})
+		case domain.ErrInvalidStatusTransition:
+			WriteErrorResponse(w, http.StatusConflict, []string{err.Error()})
+		case domain.ErrInvalidStatus:
+			WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+		default:
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{err.Error()})
+		}
+		return
+	}
+
+	w.Header().Set("ETag", deviceETag(device))
+	WriteAPIResponse(w, http.StatusOK, device)
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/helper"
+	"github.com/gorilla/mux"
+)
+
+// Media types the public key of a device is served as.
+const (
+	MediaTypePEM = "application/x-pem-file"
+	MediaTypeDER = "application/pkix-spki"
+	MediaTypeJWK = "application/jwk+json"
+)
+
+// publicKeyMediaTypes maps every accepted media type to the format it is served in.
+var publicKeyMediaTypes = map[string]string{
+	MediaTypePEM:               MediaTypePEM,
+	"text/plain":               MediaTypePEM,
+	MediaTypeDER:               MediaTypeDER,
+	"application/octet-stream": MediaTypeDER,
+	MediaTypeJWK:               MediaTypeJWK,
+	"application/json":         MediaTypeJWK,
+	"*/*":                      MediaTypePEM,
+	"application/*":            MediaTypePEM,
+}
+
+// GetPublicKey serves the public key of a device as a PKIX PEM block, as DER or as a JWK,
+// depending on the Accept header. The current key is served unless an earlier one is
+// selected with the version query parameter.
+func (s *Server) GetPublicKey(w http.ResponseWriter, r *http.Request) {
+	deviceId := mux.Vars(r)["deviceId"]
+	if !helper.IsValidUUID(deviceId) {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{"Invalid Device ID. UUID format expected"})
+		return
+	}
+
+	mediaType, ok := negotiatePublicKeyMediaType(r.Header.Get("Accept"))
+	if !ok {
+		WriteErrorResponse(w, http.StatusNotAcceptable, []string{"Supported media types: " + MediaTypePEM + ", " + MediaTypeDER + ", " + MediaTypeJWK})
+		return
+	}
+
+	device, err := s.deviceService.GetDevice(deviceId)
+	if err != nil {
+		switch err {
+		case domain.ErrDeviceNotFound:
+			WriteErrorResponse(w, http.StatusNotFound, []string{err.Error()})
+		default:
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{err.Error()})
+		}
+		return
+	}
+
+	keys := device.Keys()
+	key := keys[len(keys)-1]
+	if v := r.URL.Query().Get("version"); v != "" {
+		version, err := strconv.Atoi(v)
+		if err != nil {
+			WriteErrorResponse(w, http.StatusBadRequest, []string{"Invalid key version. Integer expected"})
+			return
+		}
+
+		key, ok = device.KeyByVersion(version)
+		if !ok {
+			WriteErrorResponse(w, http.StatusNotFound, []string{domain.ErrKeyVersionNotFound.Error()})
+			return
+		}
+	}
+
+	var body []byte
+	switch mediaType {
+	case MediaTypePEM:
+		body, err = crypto.NormalizePublicKeyPEM([]byte(key.PublicKey))
+	case MediaTypeDER:
+		body, err = crypto.PublicKeyDER([]byte(key.PublicKey))
+	case MediaTypeJWK:
+		var jwk *crypto.JWK
+		jwk, err = deviceKeyJWK(device, key)
+		if err == nil {
+			body, err = json.MarshalIndent(jwk, "", "  ")
+		}
+	}
+	if err != nil {
+		WriteErrorResponse(w, http.StatusInternalServerError, []string{err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Vary", "Accept")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// deviceKeyJWK encodes a key of the device as a JWK identified by device ID and key version.
+func deviceKeyJWK(device *domain.Device, key domain.DeviceKey) (*crypto.JWK, error) {
+	jwk, err := crypto.NewJWK([]byte(key.PublicKey))
+	if err != nil {
+		return nil, err
+	}
+
+	jwk.Kid = domain.KeyID(device.ID, key.Version)
+	jwk.Alg = crypto.JWKAlgorithm(device.Algorithm, crypto.SignatureOptions{
+		Scheme:     device.SignatureScheme,
+		SaltLength: device.PSSSaltLength,
+		Hash:       device.HashAlgorithm,
+	})
+
+	return jwk, nil
+}
+
+// negotiatePublicKeyMediaType picks the format of the public key from an Accept header,
+// honouring quality values. A missing header accepts PEM.
+func negotiatePublicKeyMediaType(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return MediaTypePEM, true
+	}
+
+	type candidate struct {
+		mediaType string
+		quality   float64
+	}
+
+	candidates := make([]candidate, 0)
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(part, ";")
+		c := candidate{mediaType: strings.ToLower(strings.TrimSpace(mediaType)), quality: 1}
+
+		for _, param := range strings.Split(params, ";") {
+			name, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if found && strings.TrimSpace(name) == "q" {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					c.quality = q
+				}
+			}
+		}
+
+		if c.quality > 0 {
+			candidates = append(candidates, c)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+
+	for _, c := range candidates {
+		if mediaType, ok := publicKeyMediaTypes[c.mediaType]; ok {
+			return mediaType, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,94 @@
+package api_test
+
+import (
+	"bytes"
+	"crypto/x509"
+	encoding "encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_GetPublicKey(t *testing.T) {
+	router := setupTestServer()
+
+	id := uuid.New().String()
+	json := []byte(`{
+		"id": "` + id + `",
+		"algorithm": "ECC",
+		"curve": "P-256",
+		"label": "Device 1"
+	}`)
+	req, err := http.NewRequest("POST", "/api/v0/devices", bytes.NewReader(json))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	getPublicKey := func(accept string, query string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", fmt.Sprintf("/api/v0/devices/%s/public-key%s", id, query), nil)
+		assert.NoError(t, err)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("PEM by default", func(t *testing.T) {
+		rr := getPublicKey("", "")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/x-pem-file", rr.Header().Get("Content-Type"))
+
+		block, _ := pem.Decode(rr.Body.Bytes())
+		assert.NotNil(t, block)
+		assert.Equal(t, "PUBLIC KEY", block.Type)
+	})
+
+	t.Run("DER", func(t *testing.T) {
+		rr := getPublicKey("application/pkix-spki", "")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/pkix-spki", rr.Header().Get("Content-Type"))
+
+		_, err := x509.ParsePKIXPublicKey(rr.Body.Bytes())
+		assert.NoError(t, err)
+	})
+
+	t.Run("JWK", func(t *testing.T) {
+		rr := getPublicKey("text/html;q=0.9, application/jwk+json", "")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/jwk+json", rr.Header().Get("Content-Type"))
+
+		var jwk map[string]string
+		assert.NoError(t, encoding.Unmarshal(rr.Body.Bytes(), &jwk))
+		assert.Equal(t, "EC", jwk["kty"])
+		assert.Equal(t, "P-256", jwk["crv"])
+		assert.Empty(t, jwk["alg"], "ES256 requires R||S signatures, devices sign in DER")
+		assert.Equal(t, id, jwk["kid"])
+		assert.Equal(t, 43, len(jwk["x"]), "coordinates should be padded to the curve size")
+	})
+
+	t.Run("unsupported media type", func(t *testing.T) {
+		rr := getPublicKey("text/html", "")
+
+		assert.Equal(t, http.StatusNotAcceptable, rr.Code)
+	})
+
+	t.Run("unknown key version", func(t *testing.T) {
+		rr := getPublicKey("", "?version=2")
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/helper"
+	"github.com/gorilla/mux"
+)
+
+// RotateKey replaces the key pair of a device and returns the device with its key history.
+func (s *Server) RotateKey(w http.ResponseWriter, r *http.Request) {
+	deviceId := mux.Vars(r)["deviceId"]
+	if !helper.IsValidUUID(deviceId) {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{"Invalid Device ID. UUID format expected"})
+		return
+	}
+
+	device, err := s.deviceService.RotateKey(deviceId)
+	if err != nil {
+		switch err {
+		case domain.ErrDeviceNotFound:
+			WriteErrorResponse(w, http.StatusNotFound, []string{err.Error()})
+		case domain.ErrDeviceNotActive:
+			WriteErrorResponse(w, http.StatusLocked, []string{err.Error()})
+		default:
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{err.Error()})
+		}
+		return
+	}
+
+	w.Header().Set("ETag", deviceETag(device))
+	WriteAPIResponse(w, http.StatusOK, device)
+}
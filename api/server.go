@@ -1,18 +1,33 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/fiskaly/coding-challenges/signing-service-challenge/service"
 	"github.com/gorilla/mux"
 )
 
+// ShutdownTimeout is how long the Server waits for requests in flight when it is stopped.
+const ShutdownTimeout = 10 * time.Second
+
+// IdempotencyKeyHeader carries the client-chosen key that makes a signing request safe to retry.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
 // Response is the generic API response container.
 type Response struct {
 	Data interface{} `json:"data"`
 }
 
+// PageResponse is the API response container for one page of a listing.
+type PageResponse struct {
+	Data       interface{} `json:"data"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+}
+
 // ErrorResponse is the generic error API response container.
 type ErrorResponse struct {
 	Errors []string `json:"errors"`
@@ -32,8 +47,10 @@ func NewServer(listenAddress string, deviceService service.DeviceService) *Serve
 	}
 }
 
-// Run registers all HandlerFuncs for the existing HTTP routes and starts the Server.
-func (s *Server) Run() error {
+// Run registers all HandlerFuncs for the existing HTTP routes and starts the Server. When ctx
+// is done, the Server stops accepting connections and returns once the requests in flight
+// have been answered, so the storage can be closed afterwards.
+func (s *Server) Run(ctx context.Context) error {
 	r := mux.NewRouter()
 
 	// Set Content-Type header to application/json
@@ -52,14 +69,68 @@ func (s *Server) Run() error {
 
 	// Transaction signing
 	r.HandleFunc("/api/v0/devices/{deviceId}/sign", s.SignTransaction).Methods(http.MethodPost)
+	r.HandleFunc("/api/v0/devices/{deviceId}/sign/batch", s.SignTransactionBatch).Methods(http.MethodPost)
+	r.HandleFunc("/api/v0/devices/{deviceId}/sign/digest", s.SignDigest).Methods(http.MethodPost)
+	r.HandleFunc("/api/v0/devices/{deviceId}/sign/upload", s.SignUpload).Methods(http.MethodPost)
+
+	// Device lifecycle
+	r.HandleFunc("/api/v0/devices/{deviceId}/deactivate", s.DeactivateDevice).Methods(http.MethodPost)
+	r.HandleFunc("/api/v0/devices/{deviceId}/reactivate", s.ReactivateDevice).Methods(http.MethodPost)
+	r.HandleFunc("/api/v0/devices/{deviceId}/decommission", s.DecommissionDevice).Methods(http.MethodPost)
+
+	// Key rotation
+	r.HandleFunc("/api/v0/devices/{deviceId}/rotate-key", s.RotateKey).Methods(http.MethodPost)
+
+	// Signature verification
+	r.HandleFunc("/api/v0/devices/{deviceId}/verify", s.VerifySignature).Methods(http.MethodPost)
 
 	// Device retrieval
 	r.HandleFunc("/api/v0/devices/{deviceId}", s.GetDevice).Methods(http.MethodGet)
 
+	// Device update
+	r.HandleFunc("/api/v0/devices/{deviceId}", s.UpdateDevice).Methods(http.MethodPatch)
+
+	// Public key of a device as PEM, DER or JWK
+	r.HandleFunc("/api/v0/devices/{deviceId}/public-key", s.GetPublicKey).Methods(http.MethodGet)
+
+	// Public keys of all active devices as a JWK set
+	r.HandleFunc("/api/v0/.well-known/jwks.json", s.GetJWKS).Methods(http.MethodGet)
+
 	// Device retrieval
 	r.HandleFunc("/api/v0/devices", s.GetAllDevices).Methods(http.MethodGet)
 
-	return http.ListenAndServe(s.listenAddress, r)
+	// Signature history
+	r.HandleFunc("/api/v0/devices/{deviceId}/transactions", s.ListTransactions).Methods(http.MethodGet)
+	r.HandleFunc("/api/v0/devices/{deviceId}/transactions/{counter}", s.GetTransaction).Methods(http.MethodGet)
+
+	// Signature chain audit
+	r.HandleFunc("/api/v0/devices/{deviceId}/audit", s.AuditDevice).Methods(http.MethodGet)
+
+	server := &http.Server{Addr: s.listenAddress, Handler: r}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
 }
 
 // WriteInternalError writes a default internal error message as an HTTP response.
@@ -101,3 +172,20 @@ func WriteAPIResponse(w http.ResponseWriter, code int, data interface{}) {
 
 	w.Write(bytes)
 }
+
+// WriteAPIPage writes one page of a listing together with the cursor of the next page.
+func WriteAPIPage(w http.ResponseWriter, code int, data interface{}, nextCursor string) {
+	w.WriteHeader(code)
+
+	response := PageResponse{
+		Data:       data,
+		NextCursor: nextCursor,
+	}
+
+	bytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		WriteInternalError(w)
+	}
+
+	w.Write(bytes)
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/helper"
+	"github.com/gorilla/mux"
+)
+
+func (s *Server) ListTransactions(w http.ResponseWriter, r *http.Request) {
+	deviceId := mux.Vars(r)["deviceId"]
+	if !helper.IsValidUUID(deviceId) {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{"Invalid Device ID. UUID format expected"})
+		return
+	}
+
+	transactions, err := s.deviceService.ListTransactions(deviceId)
+	if err != nil {
+		switch err {
+		case domain.ErrDeviceNotFound:
+			WriteErrorResponse(w, http.StatusNotFound, []string{err.Error()})
+		default:
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{err.Error()})
+		}
+		return
+	}
+
+	WriteAPIResponse(w, http.StatusOK, transactions)
+}
+
+func (s *Server) GetTransaction(w http.ResponseWriter, r *http.Request) {
+	deviceId := mux.Vars(r)["deviceId"]
+	if !helper.IsValidUUID(deviceId) {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{"Invalid Device ID. UUID format expected"})
+		return
+	}
+
+	counter, err := strconv.Atoi(mux.Vars(r)["counter"])
+	if err != nil || counter < 0 {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{"Invalid counter. Non-negative integer expected"})
+		return
+	}
+
+	transaction, err := s.deviceService.GetTransaction(deviceId, counter)
+	if err != nil {
+		switch err {
+		case domain.ErrDeviceNotFound, domain.ErrTransactionNotFound:
+			WriteErrorResponse(w, http.StatusNotFound, []string{err.Error()})
+		default:
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{err.Error()})
+		}
+		return
+	}
+
+	WriteAPIResponse(w, http.StatusOK, transaction)
+}
+
+func (s *Server) AuditDevice(w http.ResponseWriter, r *http.Request) {
+	deviceId := mux.Vars(r)["deviceId"]
+	if !helper.IsValidUUID(deviceId) {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{"Invalid Device ID. UUID format expected"})
+		return
+	}
+
+	report, err := s.deviceService.AuditDevice(deviceId)
+	if err != nil {
+		switch err {
+		case domain.ErrDeviceNotFound:
+			WriteErrorResponse(w, http.StatusNotFound, []string{err.Error()})
+		default:
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{err.Error()})
+		}
+		return
+	}
+
+	WriteAPIResponse(w, http.StatusOK, report)
+}
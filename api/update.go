@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/helper"
+	"github.com/gorilla/mux"
+)
+
+// UpdateDevice changes the label and metadata of a device. An If-Match header carrying the
+// ETag of the device makes the update fail with 412 if the device has changed since.
+func (s *Server) UpdateDevice(w http.ResponseWriter, r *http.Request) {
+	deviceId := mux.Vars(r)["deviceId"]
+	if !helper.IsValidUUID(deviceId) {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{"Invalid Device ID. UUID format expected"})
+		return
+	}
+
+	var req UpdateDeviceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{"Invalid JSON"})
+		return
+	}
+
+	update := domain.DeviceUpdate{
+		Label:    req.Label,
+		Metadata: req.Metadata,
+	}
+
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && strings.TrimSpace(ifMatch) != "*" {
+		version, ok := parseDeviceETag(ifMatch)
+		if !ok {
+			WriteErrorResponse(w, http.StatusPreconditionFailed, []string{domain.ErrVersionMismatch.Error()})
+			return
+		}
+		update.IfVersion = &version
+	}
+
+	device, err := s.deviceService.UpdateDevice(deviceId, update)
+	if err != nil {
+		switch err {
+		case domain.ErrDeviceNotFound:
+			WriteErrorResponse(w, http.StatusNotFound, []string{err.Error()})
+		case domain.ErrVersionMismatch:
+			WriteErrorResponse(w, http.StatusPreconditionFailed, []string{err.Error()})
+		case domain.ErrEmptyUpdate, domain.ErrInvalidLabel, domain.ErrInvalidMetadata:
+			WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+		default:
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{err.Error()})
+		}
+		return
+	}
+
+	w.Header().Set("ETag", deviceETag(device))
+	WriteAPIResponse(w, http.StatusOK, device)
+}
+
+// deviceETag is the entity tag of a device: its version, which changes with every update of
+// its label or metadata. Signing does not change it, so If-Match guards against concurrent
+// updates rather than against the device being in use.
+func deviceETag(device *domain.Device) string {
+	return `"` + strconv.Itoa(device.Version) + `"`
+}
+
+// parseDeviceETag returns the version an If-Match header refers to. Weak tags never
+// match, as If-Match requires a strong comparison.
+func parseDeviceETag(ifMatch string) (int, bool) {
+	tag := strings.TrimSpace(ifMatch)
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+
+	version, err := strconv.Atoi(tag[1 : len(tag)-1])
+	if err != nil {
+		return 0, false
+	}
+
+	return version, true
+}
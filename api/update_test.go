@@ -0,0 +1,133 @@
+package api_test
+
+import (
+	"bytes"
+	encoding "encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_UpdateDevice(t *testing.T) {
+	router := setupTestServer()
+
+	id := uuid.New().String()
+	json := []byte(`{
+		"id": "` + id + `",
+		"algorithm": "ECC",
+		"label": "Register 1",
+		"metadata": {"store": "berlin", "floor": "1"}
+	}`)
+	req, err := http.NewRequest("POST", "/api/v0/devices", bytes.NewReader(json))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, `"1"`, rr.Header().Get("ETag"))
+
+	patchDevice := func(body string, ifMatch string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("PATCH", "/api/v0/devices/"+id, bytes.NewReader([]byte(body)))
+		assert.NoError(t, err)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("updates label and metadata", func(t *testing.T) {
+		rr := patchDevice(`{"label": "Register 2", "metadata": {"floor": null, "till": "7"}}`, `"1"`)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+
+		var response struct {
+			Data struct {
+				Label    string            `json:"label"`
+				Metadata map[string]string `json:"metadata"`
+				Version  int               `json:"version"`
+			} `json:"data"`
+		}
+		assert.NoError(t, encoding.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "Register 2", response.Data.Label)
+		assert.Equal(t, map[string]string{"store": "berlin", "till": "7"}, response.Data.Metadata)
+		assert.Equal(t, 2, response.Data.Version)
+	})
+
+	t.Run("rejects a stale ETag", func(t *testing.T) {
+		rr := patchDevice(`{"label": "Register 3"}`, `"1"`)
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+
+		rr = patchDevice(`{"label": "Register 3"}`, `W/"2"`)
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code, "weak tags never match If-Match")
+
+		req, err := http.NewRequest("GET", "/api/v0/devices/"+id, nil)
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+		assert.Contains(t, rr.Body.String(), `"label": "Register 2"`)
+	})
+
+	t.Run("signing keeps the ETag", func(t *testing.T) {
+		req, err := http.NewRequest("POST", "/api/v0/devices/"+id+"/sign", bytes.NewReader([]byte(`{"data": "COFFEE"}`)))
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		rr = patchDevice(`{"label": "Register 3"}`, `"2"`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `"3"`, rr.Header().Get("ETag"))
+	})
+
+	t.Run("updates without If-Match", func(t *testing.T) {
+		rr := patchDevice(`{"label": "Register 4"}`, "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `"4"`, rr.Header().Get("ETag"))
+	})
+
+	t.Run("invalid updates", func(t *testing.T) {
+		for _, body := range []string{`{}`, `{"metadata": {"": "x"}}`, `not json`} {
+			rr := patchDevice(body, "")
+			assert.Equal(t, http.StatusBadRequest, rr.Code, body)
+		}
+	})
+
+	t.Run("filters the listing by metadata", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/v0/devices?metadata.store=berlin", nil)
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), id)
+
+		req, err = http.NewRequest("GET", "/api/v0/devices?metadata.store=vienna", nil)
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotContains(t, rr.Body.String(), id)
+	})
+
+	t.Run("device not found", func(t *testing.T) {
+		req, err := http.NewRequest("PATCH", "/api/v0/devices/"+uuid.New().String(), bytes.NewReader([]byte(`{"label": "x"}`)))
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
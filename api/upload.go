@@ -0,0 +1,92 @@
+package api
+
+import (
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/helper"
+	"github.com/gorilla/mux"
+)
+
+// UploadFormField is the multipart form field the file to sign is expected in. Uploads
+// without it sign their first file part.
+const UploadFormField = "file"
+
+// SignUpload signs binary content sent as an application/octet-stream body or as a file of
+// a multipart/form-data upload. The content is streamed through the hash function and its
+// digest and size are embedded into the device chain as
+// UPLOAD:<hash algorithm>:<hex digest>:<size in bytes>. The hashAlgorithm query parameter
+// overrides the signature hash of the device.
+func (s *Server) SignUpload(w http.ResponseWriter, r *http.Request) {
+	deviceId := mux.Vars(r)["deviceId"]
+	if !helper.IsValidUUID(deviceId) {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{"Invalid Device ID. UUID format expected"})
+		return
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		WriteErrorResponse(w, http.StatusUnsupportedMediaType, []string{"Content-Type must be application/octet-stream or multipart/form-data"})
+		return
+	}
+
+	var content io.Reader
+	switch mediaType {
+	case "application/octet-stream":
+		content = r.Body
+	case "multipart/form-data":
+		content, err = uploadedFile(r)
+		if err != nil {
+			WriteErrorResponse(w, http.StatusBadRequest, []string{"Multipart upload must contain a file"})
+			return
+		}
+	default:
+		WriteErrorResponse(w, http.StatusUnsupportedMediaType, []string{"Content-Type must be application/octet-stream or multipart/form-data"})
+		return
+	}
+
+	hashAlgorithm := r.URL.Query().Get("hashAlgorithm")
+	idempotencyKey := r.Header.Get(IdempotencyKeyHeader)
+
+	result, err := s.deviceService.SignStream(deviceId, hashAlgorithm, content, idempotencyKey)
+	if err != nil {
+		switch err {
+		case domain.ErrDeviceNotFound:
+			WriteErrorResponse(w, http.StatusNotFound, []string{err.Error()})
+		case domain.ErrDeviceNotActive:
+			WriteErrorResponse(w, http.StatusLocked, []string{err.Error()})
+		case domain.ErrIdempotencyKeyReused:
+			WriteErrorResponse(w, http.StatusUnprocessableEntity, []string{err.Error()})
+		case domain.ErrInvalidDigestAlgorithm, domain.ErrUploadFailed, domain.ErrInvalidIdempotencyKey:
+			WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+		default:
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{err.Error()})
+		}
+		return
+	}
+
+	WriteAPIResponse(w, http.StatusOK, result)
+}
+
+// uploadedFile returns the file part of a multipart upload without buffering it: the
+// UploadFormField part, or the first part carrying a file name if the client named the
+// field differently. Parts before it are skipped.
+func uploadedFile(r *http.Request) (io.Reader, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return nil, err
+		}
+
+		if part.FormName() == UploadFormField || part.FileName() != "" {
+			return part, nil
+		}
+	}
+}
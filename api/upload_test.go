@@ -0,0 +1,95 @@
+package api_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_SignUpload(t *testing.T) {
+	router := setupTestServer()
+
+	id := uuid.New().String()
+	json := []byte(`{
+		"id": "` + id + `",
+		"algorithm": "ECC",
+		"curve": "P-256"
+	}`)
+	req, err := http.NewRequest("POST", "/api/v0/devices", bytes.NewReader(json))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	content := []byte{0x25, 0x50, 0x44, 0x46, 0x00, 0xff, 0xfe}
+	sum := sha256.Sum256(content)
+	expected := fmt.Sprintf("UPLOAD:SHA-256:%s:%d", hex.EncodeToString(sum[:]), len(content))
+
+	signUpload := func(contentType string, body []byte) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/sign/upload", id), bytes.NewReader(body))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("octet-stream upload", func(t *testing.T) {
+		rr := signUpload("application/octet-stream", content)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), expected)
+	})
+
+	t.Run("multipart upload", func(t *testing.T) {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		assert.NoError(t, writer.WriteField("comment", "skipped"))
+		file, err := writer.CreateFormFile("document", "invoice.pdf")
+		assert.NoError(t, err)
+		_, err = file.Write(content)
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Close())
+
+		rr := signUpload(writer.FormDataContentType(), body.Bytes())
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), expected)
+	})
+
+	t.Run("multipart upload without a file", func(t *testing.T) {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		assert.NoError(t, writer.WriteField("comment", "no file"))
+		assert.NoError(t, writer.Close())
+
+		rr := signUpload(writer.FormDataContentType(), body.Bytes())
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("unsupported content type", func(t *testing.T) {
+		rr := signUpload("application/json", []byte(`{"data": "COFFEE"}`))
+		assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+	})
+
+	t.Run("invalid hash algorithm", func(t *testing.T) {
+		req, err := http.NewRequest("POST", fmt.Sprintf("/api/v0/devices/%s/sign/upload?hashAlgorithm=MD5", id), bytes.NewReader(content))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/octet-stream")
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -2,8 +2,8 @@ package crypto
 
 import (
 	"crypto/ecdsa"
-	"crypto/x509"
-	"encoding/pem"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
 )
 
 // ECCKeyPair is a DTO that holds ECC private and public keys.
@@ -13,27 +13,21 @@ type ECCKeyPair struct {
 }
 
 func (kp ECCKeyPair) GetPrivateKeyPEM() []byte {
-	privateKeyBytes, err := x509.MarshalECPrivateKey(kp.Private)
+	privateKeyPEM, err := encodePrivateKeyPEM(kp.Private)
 	if err != nil {
 		return nil
 	}
 
-	return pem.EncodeToMemory(&pem.Block{
-		Type:  "PRIVATE_KEY",
-		Bytes: privateKeyBytes,
-	})
+	return privateKeyPEM
 }
 
 func (kp ECCKeyPair) GetPublicKeyPEM() []byte {
-	publicKeyBytes, err := x509.MarshalPKIXPublicKey(kp.Public)
+	publicKeyPEM, err := encodePublicKeyPEM(kp.Public)
 	if err != nil {
 		return nil
 	}
 
-	return pem.EncodeToMemory(&pem.Block{
-		Type:  "PUBLIC_KEY",
-		Bytes: publicKeyBytes,
-	})
+	return publicKeyPEM
 }
 
 // ECCMarshaler can encode and decode an ECC key pair.
@@ -47,39 +41,49 @@ func NewECCMarshaler() ECCMarshaler {
 // Encode takes an ECCKeyPair and encodes it to be written on disk.
 // It returns the public and the private key as a byte slice.
 func (m ECCMarshaler) Encode(keyPair ECCKeyPair) ([]byte, []byte, error) {
-	privateKeyBytes, err := x509.MarshalECPrivateKey(keyPair.Private)
+	encodedPrivate, err := encodePrivateKeyPEM(keyPair.Private)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	publicKeyBytes, err := x509.MarshalPKIXPublicKey(keyPair.Public)
+	encodedPublic, err := encodePublicKeyPEM(keyPair.Public)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	encodedPrivate := pem.EncodeToMemory(&pem.Block{
-		Type:  "PRIVATE_KEY",
-		Bytes: privateKeyBytes,
-	})
-
-	encodedPublic := pem.EncodeToMemory(&pem.Block{
-		Type:  "PUBLIC_KEY",
-		Bytes: publicKeyBytes,
-	})
-
 	return encodedPublic, encodedPrivate, nil
 }
 
-// Decode assembles an ECCKeyPair from an encoded private key.
+// Decode assembles an ECCKeyPair from an encoded private key. Besides PKCS #8 it accepts
+// the SEC 1 keys written by earlier versions.
 func (m ECCMarshaler) Decode(privateKeyBytes []byte) (*ECCKeyPair, error) {
-	block, _ := pem.Decode(privateKeyBytes)
-	privateKey, err := x509.ParseECPrivateKey(block.Bytes)
+	privateKey, err := parsePrivateKeyPEM(privateKeyBytes)
 	if err != nil {
 		return nil, err
 	}
 
+	ecdsaPrivateKey, ok := privateKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, domain.ErrInvalidKeyEncoding
+	}
+
 	return &ECCKeyPair{
-		Private: privateKey,
-		Public:  &privateKey.PublicKey,
+		Private: ecdsaPrivateKey,
+		Public:  &ecdsaPrivateKey.PublicKey,
 	}, nil
 }
+
+// DecodePublicKey assembles an ECDSA public key from an encoded public key.
+func (m ECCMarshaler) DecodePublicKey(publicKeyBytes []byte) (*ecdsa.PublicKey, error) {
+	publicKey, err := ParsePublicKeyPEM(publicKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	ecdsaPublicKey, ok := publicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, domain.ErrInvalidKeyEncoding
+	}
+
+	return ecdsaPublicKey, nil
+}
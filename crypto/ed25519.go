@@ -0,0 +1,88 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+// Ed25519KeyPair is a DTO that holds Ed25519 private and public keys.
+type Ed25519KeyPair struct {
+	Public  ed25519.PublicKey
+	Private ed25519.PrivateKey
+}
+
+func (kp Ed25519KeyPair) GetPrivateKeyPEM() []byte {
+	privateKeyPEM, err := encodePrivateKeyPEM(kp.Private)
+	if err != nil {
+		return nil
+	}
+
+	return privateKeyPEM
+}
+
+func (kp Ed25519KeyPair) GetPublicKeyPEM() []byte {
+	publicKeyPEM, err := encodePublicKeyPEM(kp.Public)
+	if err != nil {
+		return nil
+	}
+
+	return publicKeyPEM
+}
+
+// Ed25519Marshaler can encode and decode an Ed25519 key pair.
+type Ed25519Marshaler struct{}
+
+// NewEd25519Marshaler creates a new Ed25519Marshaler.
+func NewEd25519Marshaler() Ed25519Marshaler {
+	return Ed25519Marshaler{}
+}
+
+// Encode takes an Ed25519KeyPair and encodes it to be written on disk.
+// It returns the public and the private key as a byte slice.
+func (m Ed25519Marshaler) Encode(keyPair Ed25519KeyPair) ([]byte, []byte, error) {
+	encodedPrivate, err := encodePrivateKeyPEM(keyPair.Private)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encodedPublic, err := encodePublicKeyPEM(keyPair.Public)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return encodedPublic, encodedPrivate, nil
+}
+
+// Decode assembles an Ed25519KeyPair from an encoded private key.
+func (m Ed25519Marshaler) Decode(privateKeyBytes []byte) (*Ed25519KeyPair, error) {
+	privateKey, err := parsePrivateKeyPEM(privateKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	ed25519PrivateKey, ok := privateKey.(ed25519.PrivateKey)
+	if !ok {
+		return nil, domain.ErrInvalidKeyEncoding
+	}
+
+	return &Ed25519KeyPair{
+		Private: ed25519PrivateKey,
+		Public:  ed25519PrivateKey.Public().(ed25519.PublicKey),
+	}, nil
+}
+
+// DecodePublicKey assembles an Ed25519 public key from an encoded public key.
+func (m Ed25519Marshaler) DecodePublicKey(publicKeyBytes []byte) (ed25519.PublicKey, error) {
+	publicKey, err := ParsePublicKeyPEM(publicKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	ed25519PublicKey, ok := publicKey.(ed25519.PublicKey)
+	if !ok {
+		return nil, domain.ErrInvalidKeyEncoding
+	}
+
+	return ed25519PublicKey, nil
+}
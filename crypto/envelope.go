@@ -0,0 +1,212 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+const (
+	// MasterKeySize is the size of a key-encryption key: AES-256.
+	MasterKeySize = 32
+
+	wrappedKeyPrefix = "enc:v1:"
+)
+
+// KeyEncrypter wraps private keys with AES-GCM under a key-encryption key (the master key).
+// Besides the current master key it can hold previous master keys, which are only used to
+// unwrap keys that have not been re-wrapped since a rotation.
+//
+// A wrapped key has the form enc:v1:<master key ID>:<base64(nonce|ciphertext)>.
+type KeyEncrypter struct {
+	currentID string
+	keys      map[string]cipher.AEAD
+}
+
+// NewKeyEncrypter creates a KeyEncrypter that wraps under current and can unwrap
+// under current and every previous master key.
+func NewKeyEncrypter(current []byte, previous ...[]byte) (*KeyEncrypter, error) {
+	e := &KeyEncrypter{
+		keys: make(map[string]cipher.AEAD),
+	}
+
+	for _, masterKey := range append([][]byte{current}, previous...) {
+		if len(masterKey) != MasterKeySize {
+			return nil, domain.ErrInvalidMasterKey
+		}
+
+		block, err := aes.NewCipher(masterKey)
+		if err != nil {
+			return nil, err
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+
+		e.keys[masterKeyID(masterKey)] = aead
+	}
+	e.currentID = masterKeyID(current)
+
+	return e, nil
+}
+
+// NewEphemeralKeyEncrypter creates a KeyEncrypter under a random master key that only
+// lives as long as the process, which suits storage that does not outlive it either.
+func NewEphemeralKeyEncrypter() *KeyEncrypter {
+	masterKey, err := GenerateMasterKey()
+	if err != nil {
+		panic(err)
+	}
+
+	e, err := NewKeyEncrypter(masterKey)
+	if err != nil {
+		panic(err)
+	}
+
+	return e
+}
+
+// Wrap encrypts a private key under the current master key. The associated data,
+// typically the device ID, binds the wrapped key to its owner: unwrapping it with
+// different associated data fails.
+func (e *KeyEncrypter) Wrap(privateKey []byte, associatedData []byte) (string, error) {
+	aead := e.keys[e.currentID]
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, privateKey, associatedData)
+
+	return wrappedKeyPrefix + e.currentID + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Unwrap decrypts a private key wrapped by Wrap under any of the known master keys.
+func (e *KeyEncrypter) Unwrap(wrapped string, associatedData []byte) ([]byte, error) {
+	keyID, sealed, err := parseWrappedKey(wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, ok := e.keys[keyID]
+	if !ok {
+		return nil, domain.ErrUnknownMasterKey
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, domain.ErrInvalidWrappedKey
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	privateKey, err := aead.Open(nil, nonce, ciphertext, associatedData)
+	if err != nil {
+		return nil, domain.ErrInvalidWrappedKey
+	}
+
+	return privateKey, nil
+}
+
+// Rewrap re-encrypts a wrapped key under the current master key. Keys stored in
+// plaintext by earlier versions of the service are wrapped as they are.
+func (e *KeyEncrypter) Rewrap(key string, associatedData []byte) (string, error) {
+	if !IsWrappedKey(key) {
+		return e.Wrap([]byte(key), associatedData)
+	}
+
+	privateKey, err := e.Unwrap(key, associatedData)
+	if err != nil {
+		return "", err
+	}
+
+	return e.Wrap(privateKey, associatedData)
+}
+
+// IsWrappedKey reports whether key is the output of Wrap rather than a plaintext PEM.
+func IsWrappedKey(key string) bool {
+	return strings.HasPrefix(key, wrappedKeyPrefix)
+}
+
+// GenerateMasterKey returns a new random master key.
+func GenerateMasterKey() ([]byte, error) {
+	masterKey := make([]byte, MasterKeySize)
+	if _, err := rand.Read(masterKey); err != nil {
+		return nil, err
+	}
+
+	return masterKey, nil
+}
+
+// DecodeMasterKey decodes a base64 encoded master key, as stored in key files
+// and environment variables.
+func DecodeMasterKey(encoded string) ([]byte, error) {
+	masterKey, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(masterKey) != MasterKeySize {
+		return nil, domain.ErrInvalidMasterKey
+	}
+
+	return masterKey, nil
+}
+
+// LoadMasterKeyFile reads the master key from path. If create is set and the file
+// does not exist, a new master key is generated and written to it.
+func LoadMasterKeyFile(path string, create bool) ([]byte, error) {
+	encoded, err := os.ReadFile(path)
+	if err == nil {
+		return DecodeMasterKey(string(encoded))
+	}
+	if !create || !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	masterKey, err := GenerateMasterKey()
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(base64.StdEncoding.EncodeToString(masterKey) + "\n"); err != nil {
+		return nil, err
+	}
+
+	return masterKey, file.Sync()
+}
+
+// masterKeyID identifies a master key without revealing it.
+func masterKeyID(masterKey []byte) string {
+	digest := sha256.Sum256(masterKey)
+	return hex.EncodeToString(digest[:4])
+}
+
+func parseWrappedKey(wrapped string) (string, []byte, error) {
+	if !IsWrappedKey(wrapped) {
+		return "", nil, domain.ErrInvalidWrappedKey
+	}
+
+	keyID, encoded, found := strings.Cut(strings.TrimPrefix(wrapped, wrappedKeyPrefix), ":")
+	if !found {
+		return "", nil, domain.ErrInvalidWrappedKey
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", nil, domain.ErrInvalidWrappedKey
+	}
+
+	return keyID, sealed, nil
+}
@@ -1,26 +1,83 @@
 package crypto
 
 import (
+	"crypto/elliptic"
+
 	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
 )
 
-func NewSignerFromDevice(algorithm string, privateKeyPEM []byte) (Signer, error) {
+// NewSignerFromDevice builds a Signer from the PEM encoded private key of a device.
+func NewSignerFromDevice(algorithm string, privateKeyPEM []byte, opts SignatureOptions) (Signer, error) {
+	var keyPair KeyPair
+	var err error
+
+	switch algorithm {
+	case domain.AlgorithmRSA:
+		marshaler := NewRSAMarshaler()
+		keyPair, err = marshaler.Unmarshal(privateKeyPEM)
+	case domain.AlgorithmECC:
+		keyPair, err = NewECCMarshaler().Decode(privateKeyPEM)
+	case domain.AlgorithmEd25519:
+		keyPair, err = NewEd25519Marshaler().Decode(privateKeyPEM)
+	default:
+		return nil, domain.ErrInvalidAlgorithm
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSignerFromKeyPair(keyPair, opts)
+}
+
+// NewSignerFromKeyPair builds a Signer for the private key of a key pair.
+func NewSignerFromKeyPair(keyPair KeyPair, opts SignatureOptions) (Signer, error) {
+	switch kp := keyPair.(type) {
+	case *RSAKeyPair:
+		if opts.Scheme == domain.SchemePSS {
+			return NewRSAPSSSigner(kp.Private, opts.SaltLength, hashFunction(opts)), nil
+		}
+		return NewRSASigner(kp.Private, hashFunction(opts)), nil
+
+	case *ECCKeyPair:
+		return NewECDSASigner(kp.Private, hashFunction(opts)), nil
+
+	case *Ed25519KeyPair:
+		return NewEd25519Signer(kp.Private), nil
+
+	default:
+		return nil, domain.ErrInvalidAlgorithm
+	}
+}
+
+// NewVerifierFromDevice builds a Verifier from the PEM encoded public key of a device.
+func NewVerifierFromDevice(algorithm string, publicKeyPEM []byte, opts SignatureOptions) (Verifier, error) {
 	switch algorithm {
 	case domain.AlgorithmRSA:
 		marshaler := NewRSAMarshaler()
-		keyPair, err := marshaler.Unmarshal(privateKeyPEM)
+		publicKey, err := marshaler.UnmarshalPublicKey(publicKeyPEM)
 		if err != nil {
 			return nil, err
 		}
-		return NewRSASigner(keyPair.Private), nil
+		if opts.Scheme == domain.SchemePSS {
+			return NewRSAPSSVerifier(publicKey, opts.SaltLength, hashFunction(opts)), nil
+		}
+		return NewRSAVerifier(publicKey, hashFunction(opts)), nil
 
 	case domain.AlgorithmECC:
 		marshaler := NewECCMarshaler()
-		keyPair, err := marshaler.Decode(privateKeyPEM)
+		publicKey, err := marshaler.DecodePublicKey(publicKeyPEM)
+		if err != nil {
+			return nil, err
+		}
+		return NewECDSAVerifier(publicKey, hashFunction(opts)), nil
+
+	case domain.AlgorithmEd25519:
+		marshaler := NewEd25519Marshaler()
+		publicKey, err := marshaler.DecodePublicKey(publicKeyPEM)
 		if err != nil {
 			return nil, err
 		}
-		return NewECDSASigner(keyPair.Private), nil
+		return NewEd25519Verifier(publicKey), nil
 
 	default:
 		return nil, domain.ErrInvalidAlgorithm
@@ -29,14 +86,61 @@ func NewSignerFromDevice(algorithm string, privateKeyPEM []byte) (Signer, error)
 
 type Generator interface {
 	Generate() (KeyPair, error)
+	Parameters() KeyParameters
 }
 
-func NewGenerator(algorithm string) (Generator, error) {
+// ResolveKeyParameters validates the key parameters for the algorithm and fills in the
+// defaults that NewGenerator would apply, without generating a key.
+func ResolveKeyParameters(algorithm string, params KeyParameters) (KeyParameters, error) {
+	gen, err := NewGenerator(algorithm, params)
+	if err != nil {
+		return KeyParameters{}, err
+	}
+
+	return gen.Parameters(), nil
+}
+
+// NewGenerator validates the key parameters for the algorithm and returns a Generator for them.
+// Parameters left empty fall back to RSA 2048 bits and the ECC curve P-384.
+func NewGenerator(algorithm string, params KeyParameters) (Generator, error) {
 	switch algorithm {
 	case domain.AlgorithmRSA:
-		return &RSAGenerator{}, nil
+		if params.Curve != "" {
+			return nil, domain.ErrInvalidKeyParameters
+		}
+
+		switch params.KeySize {
+		case 0:
+			return &RSAGenerator{KeySize: domain.RSAKeySize2048}, nil
+		case domain.RSAKeySize2048, domain.RSAKeySize3072, domain.RSAKeySize4096:
+			return &RSAGenerator{KeySize: params.KeySize}, nil
+		default:
+			return nil, domain.ErrInvalidKeyParameters
+		}
+
 	case domain.AlgorithmECC:
-		return &ECCGenerator{}, nil
+		if params.KeySize != 0 {
+			return nil, domain.ErrInvalidKeyParameters
+		}
+
+		switch params.Curve {
+		case domain.CurveP256:
+			return &ECCGenerator{Curve: elliptic.P256()}, nil
+		case "", domain.CurveP384:
+			return &ECCGenerator{Curve: elliptic.P384()}, nil
+		case domain.CurveP521:
+			return &ECCGenerator{Curve: elliptic.P521()}, nil
+		default:
+			return nil, domain.ErrInvalidKeyParameters
+		}
+
+	case domain.AlgorithmEd25519:
+		if params != (KeyParameters{}) {
+			return nil, domain.ErrInvalidKeyParameters
+		}
+
+		return &Ed25519Generator{}, nil
+
 	default:
 		return nil, domain.ErrInvalidAlgorithm
 	}
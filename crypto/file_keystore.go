@@ -0,0 +1,217 @@
+package crypto
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/google/uuid"
+)
+
+const keyFileExtension = ".key"
+
+// FileKeyStore is a soft-HSM: a KeyStore that keeps every private key in a file of its
+// own, wrapped under the master key of a KeyEncrypter with the handle as associated data.
+// It stands in for a hardware module wherever keys have to outlive the process.
+type FileKeyStore struct {
+	mu        sync.RWMutex
+	dir       string
+	encrypter *KeyEncrypter
+}
+
+// storedKey is the content of a key file.
+type storedKey struct {
+	Algorithm  string `json:"algorithm"`
+	PrivateKey string `json:"privateKey"`
+}
+
+// NewFileKeyStore opens or creates a FileKeyStore in dir.
+func NewFileKeyStore(dir string, encrypter *KeyEncrypter) (*FileKeyStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	return &FileKeyStore{
+		mu:        sync.RWMutex{},
+		dir:       dir,
+		encrypter: encrypter,
+	}, nil
+}
+
+func (ks *FileKeyStore) Generate(algorithm string, params KeyParameters) (*GeneratedKey, error) {
+	gen, err := NewGenerator(algorithm, params)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPair, err := gen.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	handle := uuid.New().String()
+
+	wrapped, err := ks.encrypter.Wrap(keyPair.GetPrivateKeyPEM(), []byte(handle))
+	if err != nil {
+		return nil, err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if err := ks.write(handle, storedKey{Algorithm: algorithm, PrivateKey: wrapped}); err != nil {
+		return nil, err
+	}
+
+	return &GeneratedKey{
+		Handle:       handle,
+		PublicKeyPEM: keyPair.GetPublicKeyPEM(),
+		Parameters:   gen.Parameters(),
+	}, nil
+}
+
+func (ks *FileKeyStore) Signer(handle string, opts SignatureOptions) (Signer, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	key, err := ks.read(handle)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKeyPEM, err := ks.encrypter.Unwrap(key.PrivateKey, []byte(handle))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSignerFromDevice(key.Algorithm, privateKeyPEM, opts)
+}
+
+func (ks *FileKeyStore) Delete(handle string) error {
+	path, err := ks.path(handle)
+	if err != nil {
+		return err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if err := os.Remove(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return domain.ErrKeyNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Rewrap re-encrypts every key file under the current master key of the store's
+// KeyEncrypter and returns the number of re-wrapped keys.
+func (ks *FileKeyStore) Rewrap() (int, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	entries, err := os.ReadDir(ks.dir)
+	if err != nil {
+		return 0, err
+	}
+
+	rewrapped := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), keyFileExtension) {
+			continue
+		}
+		handle := strings.TrimSuffix(entry.Name(), keyFileExtension)
+
+		key, err := ks.read(handle)
+		if err != nil {
+			return rewrapped, err
+		}
+
+		key.PrivateKey, err = ks.encrypter.Rewrap(key.PrivateKey, []byte(handle))
+		if err != nil {
+			return rewrapped, err
+		}
+
+		if err := ks.write(handle, *key); err != nil {
+			return rewrapped, err
+		}
+
+		rewrapped++
+	}
+
+	return rewrapped, nil
+}
+
+// path maps a handle to its key file. Handles are UUIDs, which keeps them from
+// escaping the key directory.
+func (ks *FileKeyStore) path(handle string) (string, error) {
+	if _, err := uuid.Parse(handle); err != nil {
+		return "", domain.ErrKeyNotFound
+	}
+
+	return filepath.Join(ks.dir, handle+keyFileExtension), nil
+}
+
+func (ks *FileKeyStore) read(handle string) (*storedKey, error) {
+	path, err := ks.path(handle)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, domain.ErrKeyNotFound
+		}
+		return nil, err
+	}
+
+	var key storedKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// write replaces the key file of handle through a synced temporary file, so a crash
+// never leaves a partially written key behind.
+func (ks *FileKeyStore) write(handle string, key storedKey) error {
+	path, err := ks.path(handle)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(ks.dir, handle+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
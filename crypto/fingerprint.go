@@ -0,0 +1,21 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+// PublicKeyFingerprint identifies a PEM encoded public key by the hex encoded SHA-256
+// digest of its DER bytes, independent of PEM headers and line wrapping.
+func PublicKeyFingerprint(publicKeyPEM []byte) (string, error) {
+	block, _ := pem.Decode(publicKeyPEM)
+	if block == nil {
+		return "", domain.ErrInvalidKeyEncoding
+	}
+
+	digest := sha256.Sum256(block.Bytes)
+	return hex.EncodeToString(digest[:]), nil
+}
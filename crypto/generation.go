@@ -2,18 +2,27 @@ package crypto
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 )
 
+// KeyParameters selects the strength of a generated key pair.
+// KeySize applies to RSA only and Curve to ECC only.
+type KeyParameters struct {
+	KeySize int
+	Curve   string
+}
+
 // RSAGenerator generates a RSA key pair.
-type RSAGenerator struct{}
+type RSAGenerator struct {
+	KeySize int
+}
 
 // Generate generates a new RSAKeyPair.
 func (g *RSAGenerator) Generate() (KeyPair, error) {
-	// Security has been ignored for the sake of simplicity.
-	key, err := rsa.GenerateKey(rand.Reader, 512)
+	key, err := rsa.GenerateKey(rand.Reader, g.KeySize)
 	if err != nil {
 		return nil, err
 	}
@@ -24,13 +33,19 @@ func (g *RSAGenerator) Generate() (KeyPair, error) {
 	}, nil
 }
 
+// Parameters returns the key parameters of the generated key pairs.
+func (g *RSAGenerator) Parameters() KeyParameters {
+	return KeyParameters{KeySize: g.KeySize}
+}
+
 // ECCGenerator generates an ECC key pair.
-type ECCGenerator struct{}
+type ECCGenerator struct {
+	Curve elliptic.Curve
+}
 
 // Generate generates a new ECCKeyPair.
 func (g *ECCGenerator) Generate() (KeyPair, error) {
-	// Security has been ignored for the sake of simplicity.
-	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	key, err := ecdsa.GenerateKey(g.Curve, rand.Reader)
 	if err != nil {
 		return nil, err
 	}
@@ -40,3 +55,30 @@ func (g *ECCGenerator) Generate() (KeyPair, error) {
 		Private: key,
 	}, nil
 }
+
+// Parameters returns the key parameters of the generated key pairs.
+func (g *ECCGenerator) Parameters() KeyParameters {
+	return KeyParameters{Curve: g.Curve.Params().Name}
+}
+
+// Ed25519Generator generates an Ed25519 key pair.
+type Ed25519Generator struct{}
+
+// Generate generates a new Ed25519KeyPair.
+func (g *Ed25519Generator) Generate() (KeyPair, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Ed25519KeyPair{
+		Public:  public,
+		Private: private,
+	}, nil
+}
+
+// Parameters returns the key parameters of the generated key pairs.
+// Ed25519 keys have a fixed size, so there are none.
+func (g *Ed25519Generator) Parameters() KeyParameters {
+	return KeyParameters{}
+}
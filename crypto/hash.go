@@ -0,0 +1,98 @@
+package crypto
+
+import (
+	"crypto"
+	"hash"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+
+	// registers SHA3-256 with crypto.Hash
+	_ "golang.org/x/crypto/sha3"
+)
+
+// signatureHashes are the hash algorithms RSA and ECDSA signatures can digest the signed
+// data with.
+var signatureHashes = map[string]crypto.Hash{
+	domain.HashSHA256:   crypto.SHA256,
+	domain.HashSHA384:   crypto.SHA384,
+	domain.HashSHA512:   crypto.SHA512,
+	domain.HashSHA3_256: crypto.SHA3_256,
+}
+
+// SignatureHash names the hash algorithm signatures made with the options digest the
+// signed data with. Options without a hash predate the choice and hash with SHA-256.
+// Ed25519 signs the data itself and hashes it internally with SHA-512.
+func SignatureHash(algorithm string, opts SignatureOptions) string {
+	if algorithm == domain.AlgorithmEd25519 {
+		return domain.HashSHA512
+	}
+
+	if opts.Hash == "" {
+		return domain.HashSHA256
+	}
+
+	return opts.Hash
+}
+
+// defaultSignatureHash pairs a key with the hash of matching security strength, following
+// NIST SP 800-57: SHA-256 up to 128 bits (P-256, RSA 2048 and 3072), SHA-384 up to 192
+// bits (P-384, RSA 4096) and SHA-512 beyond (P-521).
+func defaultSignatureHash(algorithm string, params KeyParameters) string {
+	switch algorithm {
+	case domain.AlgorithmRSA:
+		if params.KeySize >= domain.RSAKeySize4096 {
+			return domain.HashSHA384
+		}
+		return domain.HashSHA256
+
+	case domain.AlgorithmECC:
+		switch params.Curve {
+		case domain.CurveP384:
+			return domain.HashSHA384
+		case domain.CurveP521:
+			return domain.HashSHA512
+		default:
+			return domain.HashSHA256
+		}
+
+	default:
+		return domain.HashSHA512
+	}
+}
+
+// DigestSize returns the size in bytes of digests of a hash algorithm signatures can digest
+// with.
+func DigestSize(hashAlgorithm string) (int, bool) {
+	hash, ok := signatureHashes[hashAlgorithm]
+	if !ok {
+		return 0, false
+	}
+
+	return hash.Size(), true
+}
+
+// NewHash returns a running hash of a hash algorithm signatures can digest with, for
+// content too large to hash in one go.
+func NewHash(hashAlgorithm string) (hash.Hash, bool) {
+	h, ok := signatureHashes[hashAlgorithm]
+	if !ok {
+		return nil, false
+	}
+
+	return h.New(), true
+}
+
+// hashFunction returns the implementation of a hash algorithm of the signature options.
+func hashFunction(opts SignatureOptions) crypto.Hash {
+	if hash, ok := signatureHashes[opts.Hash]; ok {
+		return hash
+	}
+
+	return crypto.SHA256
+}
+
+func digest(hash crypto.Hash, data []byte) []byte {
+	h := hash.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
@@ -0,0 +1,108 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+// JWK is a public key in the JSON Web Key format of RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// NewJWK converts a standard or legacy PEM public key into a signature JWK.
+func NewJWK(publicKeyPEM []byte) (*JWK, error) {
+	publicKey, err := ParsePublicKeyPEM(publicKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	switch pk := publicKey.(type) {
+	case *rsa.PublicKey:
+		return &JWK{
+			Kty: "RSA",
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(pk.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pk.E)).Bytes()),
+		}, nil
+
+	case *ecdsa.PublicKey:
+		ecdhKey, err := pk.ECDH()
+		if err != nil {
+			return nil, err
+		}
+
+		// uncompressed point: 0x04 | X | Y, both padded to the size of the curve
+		point := ecdhKey.Bytes()
+		size := (len(point) - 1) / 2
+
+		return &JWK{
+			Kty: "EC",
+			Use: "sig",
+			Crv: pk.Curve.Params().Name,
+			X:   base64.RawURLEncoding.EncodeToString(point[1 : 1+size]),
+			Y:   base64.RawURLEncoding.EncodeToString(point[1+size:]),
+		}, nil
+
+	case ed25519.PublicKey:
+		return &JWK{
+			Kty: "OKP",
+			Use: "sig",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pk),
+		}, nil
+
+	default:
+		return nil, domain.ErrInvalidKeyEncoding
+	}
+}
+
+// JWKAlgorithm names the JWA signature algorithm of a device, or returns an empty string
+// if its signatures match none. JWA knows no SHA-3 algorithm, RSA-PSS algorithms require
+// a salt as long as the hash, and ECDSA algorithms require the fixed-length R||S encoding
+// where devices sign in ASN.1 DER, so ECC keys are published without an algorithm.
+func JWKAlgorithm(algorithm string, opts SignatureOptions) string {
+	hash := SignatureHash(algorithm, opts)
+
+	suffix, ok := jwaHashSuffixes[hash]
+	if !ok && algorithm != domain.AlgorithmEd25519 {
+		return ""
+	}
+
+	switch algorithm {
+	case domain.AlgorithmRSA:
+		if opts.Scheme == domain.SchemePSS {
+			if opts.SaltLength == 0 || opts.SaltLength == signatureHashes[hash].Size() {
+				return "PS" + suffix
+			}
+			return ""
+		}
+		return "RS" + suffix
+
+	case domain.AlgorithmEd25519:
+		return "EdDSA"
+
+	default:
+		return ""
+	}
+}
+
+// jwaHashSuffixes name the hash algorithms in JWA algorithm names.
+var jwaHashSuffixes = map[string]string{
+	domain.HashSHA256: "256",
+	domain.HashSHA384: "384",
+	domain.HashSHA512: "512",
+}
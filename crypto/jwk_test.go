@@ -0,0 +1,70 @@
+package crypto_test
+
+import (
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewJWK(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		params    crypto.KeyParameters
+		kty       string
+		crv       string
+		xLength   int
+	}{
+		{algorithm: "RSA", params: crypto.KeyParameters{KeySize: 2048}, kty: "RSA"},
+		{algorithm: "ECC", params: crypto.KeyParameters{Curve: "P-256"}, kty: "EC", crv: "P-256", xLength: 43},
+		{algorithm: "ECC", params: crypto.KeyParameters{Curve: "P-521"}, kty: "EC", crv: "P-521", xLength: 88},
+		{algorithm: "ED25519", kty: "OKP", crv: "Ed25519", xLength: 43},
+	}
+
+	for _, tt := range tests {
+		gen, err := crypto.NewGenerator(tt.algorithm, tt.params)
+		assert.NoError(t, err)
+		keyPair, err := gen.Generate()
+		assert.NoError(t, err)
+
+		jwk, err := crypto.NewJWK(keyPair.GetPublicKeyPEM())
+		assert.NoError(t, err)
+		assert.Equal(t, tt.kty, jwk.Kty, tt.algorithm)
+		assert.Equal(t, "sig", jwk.Use)
+		assert.Equal(t, tt.crv, jwk.Crv, tt.algorithm)
+
+		if tt.kty == "RSA" {
+			assert.Equal(t, "AQAB", jwk.E)
+			assert.Equal(t, 342, len(jwk.N))
+			continue
+		}
+		assert.Equal(t, tt.xLength, len(jwk.X), "%s coordinates should be padded to the curve size", tt.crv)
+	}
+
+	_, err := crypto.NewJWK([]byte("not a key"))
+	assert.ErrorIs(t, err, domain.ErrInvalidKeyEncoding)
+}
+
+func TestJWKAlgorithm(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		opts      crypto.SignatureOptions
+		want      string
+	}{
+		// ECDSA signatures are DER encoded, JWA ES* algorithms expect R||S
+		{algorithm: "ECC", opts: crypto.SignatureOptions{Hash: "SHA-256"}, want: ""},
+		{algorithm: "ECC", opts: crypto.SignatureOptions{Hash: "SHA-384"}, want: ""},
+		{algorithm: "ECC", opts: crypto.SignatureOptions{Hash: "SHA3-256"}, want: ""},
+		{algorithm: "RSA", opts: crypto.SignatureOptions{Scheme: "PKCS1v15"}, want: "RS256"},
+		{algorithm: "RSA", opts: crypto.SignatureOptions{Scheme: "PKCS1v15", Hash: "SHA-384"}, want: "RS384"},
+		{algorithm: "RSA", opts: crypto.SignatureOptions{Scheme: "PKCS1v15", Hash: "SHA3-256"}, want: ""},
+		{algorithm: "RSA", opts: crypto.SignatureOptions{Scheme: "PSS", Hash: "SHA-512"}, want: "PS512"},
+		{algorithm: "RSA", opts: crypto.SignatureOptions{Scheme: "PSS", SaltLength: 32, Hash: "SHA-512"}, want: ""},
+		{algorithm: "ED25519", opts: crypto.SignatureOptions{Hash: "SHA-512"}, want: "EdDSA"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, crypto.JWKAlgorithm(tt.algorithm, tt.opts), "%s %+v", tt.algorithm, tt.opts)
+	}
+}
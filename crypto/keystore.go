@@ -0,0 +1,91 @@
+package crypto
+
+import (
+	"sync"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/google/uuid"
+)
+
+// KeyStore holds the private keys of devices. Keys are generated inside the store and
+// referenced by an opaque handle afterwards, so the private key material never has to
+// leave the store and custody can be delegated to an HSM.
+type KeyStore interface {
+	// Generate creates a key pair for the algorithm and returns its handle and public key.
+	Generate(algorithm string, params KeyParameters) (*GeneratedKey, error)
+	// Signer returns a Signer that signs with the private key behind handle.
+	Signer(handle string, opts SignatureOptions) (Signer, error)
+	// Delete destroys the private key behind handle.
+	Delete(handle string) error
+}
+
+// GeneratedKey describes a key pair created by a KeyStore.
+type GeneratedKey struct {
+	Handle       string
+	PublicKeyPEM []byte
+	Parameters   KeyParameters
+}
+
+// SoftwareKeyStore is an in-process KeyStore. Its keys live as long as the process,
+// which makes it the counterpart of the in-memory repository.
+type SoftwareKeyStore struct {
+	mu   sync.RWMutex
+	keys map[string]KeyPair
+}
+
+// NewSoftwareKeyStore creates an empty SoftwareKeyStore.
+func NewSoftwareKeyStore() *SoftwareKeyStore {
+	return &SoftwareKeyStore{
+		mu:   sync.RWMutex{},
+		keys: make(map[string]KeyPair),
+	}
+}
+
+func (ks *SoftwareKeyStore) Generate(algorithm string, params KeyParameters) (*GeneratedKey, error) {
+	gen, err := NewGenerator(algorithm, params)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPair, err := gen.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	handle := uuid.New().String()
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.keys[handle] = keyPair
+
+	return &GeneratedKey{
+		Handle:       handle,
+		PublicKeyPEM: keyPair.GetPublicKeyPEM(),
+		Parameters:   gen.Parameters(),
+	}, nil
+}
+
+func (ks *SoftwareKeyStore) Signer(handle string, opts SignatureOptions) (Signer, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	keyPair, exists := ks.keys[handle]
+	if !exists {
+		return nil, domain.ErrKeyNotFound
+	}
+
+	return NewSignerFromKeyPair(keyPair, opts)
+}
+
+func (ks *SoftwareKeyStore) Delete(handle string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if _, exists := ks.keys[handle]; !exists {
+		return domain.ErrKeyNotFound
+	}
+
+	delete(ks.keys, handle)
+	return nil
+}
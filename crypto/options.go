@@ -0,0 +1,84 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/rsa"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+// SignatureOptions tunes how signatures of a device are produced and verified.
+type SignatureOptions struct {
+	// Scheme is the RSA signature scheme, PKCS1v15 or PSS. It is empty for other algorithms.
+	Scheme string
+	// SaltLength is the RSA-PSS salt length in bytes. Zero uses the length of the hash.
+	SaltLength int
+	// Hash is the hash algorithm the signed data is digested with, see SignatureHash.
+	Hash string
+}
+
+// ResolveSignatureOptions validates the options for the algorithm and the resolved key
+// parameters and fills in the defaults. RSA devices sign with PKCS1v15 unless PSS is
+// requested, and the hash defaults to the one matching the strength of the key.
+func ResolveSignatureOptions(algorithm string, params KeyParameters, opts SignatureOptions) (SignatureOptions, error) {
+	switch {
+	case opts.Hash == "":
+		opts.Hash = defaultSignatureHash(algorithm, params)
+	case algorithm == domain.AlgorithmEd25519:
+		// Ed25519 is bound to SHA-512
+		if opts.Hash != domain.HashSHA512 {
+			return SignatureOptions{}, domain.ErrInvalidHashAlgorithm
+		}
+	default:
+		if _, ok := signatureHashes[opts.Hash]; !ok {
+			return SignatureOptions{}, domain.ErrInvalidHashAlgorithm
+		}
+	}
+
+	if algorithm != domain.AlgorithmRSA {
+		if opts.Scheme != "" {
+			return SignatureOptions{}, domain.ErrInvalidSignatureScheme
+		}
+		if opts.SaltLength != 0 {
+			return SignatureOptions{}, domain.ErrInvalidSaltLength
+		}
+
+		return opts, nil
+	}
+
+	switch opts.Scheme {
+	case "", domain.SchemePKCS1v15:
+		if opts.SaltLength != 0 {
+			return SignatureOptions{}, domain.ErrInvalidSaltLength
+		}
+
+		return SignatureOptions{Scheme: domain.SchemePKCS1v15, Hash: opts.Hash}, nil
+
+	case domain.SchemePSS:
+		if opts.SaltLength < 0 || opts.SaltLength > maxPSSSaltLength(params.KeySize, hashFunction(opts)) {
+			return SignatureOptions{}, domain.ErrInvalidSaltLength
+		}
+
+		return opts, nil
+
+	default:
+		return SignatureOptions{}, domain.ErrInvalidSignatureScheme
+	}
+}
+
+// pssOptions translates the salt length into the options of crypto/rsa.
+func pssOptions(saltLength int, hash crypto.Hash) *rsa.PSSOptions {
+	if saltLength == 0 {
+		saltLength = rsa.PSSSaltLengthEqualsHash
+	}
+
+	return &rsa.PSSOptions{
+		SaltLength: saltLength,
+		Hash:       hash,
+	}
+}
+
+// maxPSSSaltLength is the largest salt that fits into an encoded message of an RSA key of keySize bits.
+func maxPSSSaltLength(keySize int, hash crypto.Hash) int {
+	return (keySize-1+7)/8 - hash.Size() - 2
+}
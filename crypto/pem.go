@@ -0,0 +1,110 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+// Standard PEM block types: PKIX SubjectPublicKeyInfo and PKCS #8 private keys, as
+// written for every algorithm and understood by OpenSSL and most libraries.
+const (
+	PEMTypePublicKey  = "PUBLIC KEY"
+	PEMTypePrivateKey = "PRIVATE KEY"
+)
+
+// Block types written by earlier versions of the service. Keys stored with them are
+// still decoded, but never written again.
+const (
+	legacyPEMTypeRSAPublicKey  = "RSA_PUBLIC_KEY"  // PKCS #1
+	legacyPEMTypeRSAPrivateKey = "RSA_PRIVATE_KEY" // PKCS #1
+	legacyPEMTypePublicKey     = "PUBLIC_KEY"      // PKIX
+	legacyPEMTypePrivateKey    = "PRIVATE_KEY"     // SEC 1 for ECDSA, PKCS #8 for Ed25519
+)
+
+// encodePublicKeyPEM encodes a public key as a PKIX "PUBLIC KEY" block.
+func encodePublicKeyPEM(publicKey crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: PEMTypePublicKey, Bytes: der}), nil
+}
+
+// encodePrivateKeyPEM encodes a private key as a PKCS #8 "PRIVATE KEY" block.
+func encodePrivateKeyPEM(privateKey crypto.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: PEMTypePrivateKey, Bytes: der}), nil
+}
+
+// ParsePublicKeyPEM decodes a public key from a standard or legacy PEM block.
+func ParsePublicKeyPEM(publicKeyPEM []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(publicKeyPEM)
+	if block == nil {
+		return nil, domain.ErrInvalidKeyEncoding
+	}
+
+	switch block.Type {
+	case legacyPEMTypeRSAPublicKey, "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case PEMTypePublicKey, legacyPEMTypePublicKey:
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, domain.ErrInvalidKeyEncoding
+	}
+}
+
+// parsePrivateKeyPEM decodes a private key from a standard or legacy PEM block.
+func parsePrivateKeyPEM(privateKeyPEM []byte) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, domain.ErrInvalidKeyEncoding
+	}
+
+	switch block.Type {
+	case legacyPEMTypeRSAPrivateKey, "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case PEMTypePrivateKey, legacyPEMTypePrivateKey:
+		privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err == nil {
+			return privateKey, nil
+		}
+		// legacy ECDSA keys are SEC 1 encoded despite the generic block type
+		if ecPrivateKey, ecErr := x509.ParseECPrivateKey(block.Bytes); ecErr == nil {
+			return ecPrivateKey, nil
+		}
+		return nil, err
+	default:
+		return nil, domain.ErrInvalidKeyEncoding
+	}
+}
+
+// NormalizePublicKeyPEM re-encodes a standard or legacy PEM public key as a PKIX
+// "PUBLIC KEY" block.
+func NormalizePublicKeyPEM(publicKeyPEM []byte) ([]byte, error) {
+	publicKey, err := ParsePublicKeyPEM(publicKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodePublicKeyPEM(publicKey)
+}
+
+// PublicKeyDER returns the DER encoded PKIX SubjectPublicKeyInfo of a PEM public key.
+func PublicKeyDER(publicKeyPEM []byte) ([]byte, error) {
+	publicKey, err := ParsePublicKeyPEM(publicKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	return x509.MarshalPKIXPublicKey(publicKey)
+}
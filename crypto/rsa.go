@@ -2,8 +2,8 @@ package crypto
 
 import (
 	"crypto/rsa"
-	"crypto/x509"
-	"encoding/pem"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
 )
 
 // RSAKeyPair is a DTO that holds RSA private and public keys.
@@ -13,19 +13,21 @@ type RSAKeyPair struct {
 }
 
 func (kp RSAKeyPair) GetPrivateKeyPEM() []byte {
-	privateKeyBytes := x509.MarshalPKCS1PrivateKey(kp.Private)
-	return pem.EncodeToMemory(&pem.Block{
-		Type:  "RSA_PRIVATE_KEY",
-		Bytes: privateKeyBytes,
-	})
+	privateKeyPEM, err := encodePrivateKeyPEM(kp.Private)
+	if err != nil {
+		return nil
+	}
+
+	return privateKeyPEM
 }
 
 func (kp RSAKeyPair) GetPublicKeyPEM() []byte {
-	publicKeyBytes := x509.MarshalPKCS1PublicKey(kp.Public)
-	return pem.EncodeToMemory(&pem.Block{
-		Type:  "RSA_PUBLIC_KEY",
-		Bytes: publicKeyBytes,
-	})
+	publicKeyPEM, err := encodePublicKeyPEM(kp.Public)
+	if err != nil {
+		return nil
+	}
+
+	return publicKeyPEM
 }
 
 // RSAMarshaler can encode and decode an RSA key pair.
@@ -39,32 +41,50 @@ func NewRSAMarshaler() RSAMarshaler {
 // Marshal takes an RSAKeyPair and encodes it to be written on disk.
 // It returns the public and the private key as a byte slice.
 func (m *RSAMarshaler) Marshal(keyPair RSAKeyPair) ([]byte, []byte, error) {
-	privateKeyBytes := x509.MarshalPKCS1PrivateKey(keyPair.Private)
-	publicKeyBytes := x509.MarshalPKCS1PublicKey(keyPair.Public)
-
-	encodedPrivate := pem.EncodeToMemory(&pem.Block{
-		Type:  "RSA_PRIVATE_KEY",
-		Bytes: privateKeyBytes,
-	})
+	encodedPrivate, err := encodePrivateKeyPEM(keyPair.Private)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	encodePublic := pem.EncodeToMemory(&pem.Block{
-		Type:  "RSA_PUBLIC_KEY",
-		Bytes: publicKeyBytes,
-	})
+	encodedPublic, err := encodePublicKeyPEM(keyPair.Public)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	return encodePublic, encodedPrivate, nil
+	return encodedPublic, encodedPrivate, nil
 }
 
 // Unmarshal takes an encoded RSA private key and transforms it into a rsa.PrivateKey.
+// Besides PKCS #8 it accepts the PKCS #1 keys written by earlier versions.
 func (m *RSAMarshaler) Unmarshal(privateKeyBytes []byte) (*RSAKeyPair, error) {
-	block, _ := pem.Decode(privateKeyBytes)
-	privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	privateKey, err := parsePrivateKeyPEM(privateKeyBytes)
 	if err != nil {
 		return nil, err
 	}
 
+	rsaPrivateKey, ok := privateKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, domain.ErrInvalidKeyEncoding
+	}
+
 	return &RSAKeyPair{
-		Private: privateKey,
-		Public:  &privateKey.PublicKey,
+		Private: rsaPrivateKey,
+		Public:  &rsaPrivateKey.PublicKey,
 	}, nil
 }
+
+// UnmarshalPublicKey takes an encoded RSA public key and transforms it into a rsa.PublicKey.
+// Besides PKIX it accepts the PKCS #1 keys written by earlier versions.
+func (m *RSAMarshaler) UnmarshalPublicKey(publicKeyBytes []byte) (*rsa.PublicKey, error) {
+	publicKey, err := ParsePublicKeyPEM(publicKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	rsaPublicKey, ok := publicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, domain.ErrInvalidKeyEncoding
+	}
+
+	return rsaPublicKey, nil
+}
@@ -3,9 +3,9 @@ package crypto
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/rsa"
-	"crypto/sha256"
 )
 
 // Signer defines a contract for different types of signing implementations.
@@ -15,26 +15,57 @@ type Signer interface {
 
 type RSASigner struct {
 	privateKey *rsa.PrivateKey
+	hash       crypto.Hash
+	pss        *rsa.PSSOptions
 }
 
-func NewRSASigner(privateKey *rsa.PrivateKey) *RSASigner {
-	return &RSASigner{privateKey: privateKey}
+// NewRSASigner creates a Signer for the RSASSA-PKCS1-v1_5 scheme over the given hash.
+func NewRSASigner(privateKey *rsa.PrivateKey, hash crypto.Hash) *RSASigner {
+	return &RSASigner{privateKey: privateKey, hash: hash}
+}
+
+// NewRSAPSSSigner creates a Signer for the RSASSA-PSS scheme over the given hash.
+// A saltLength of zero uses the length of the hash.
+func NewRSAPSSSigner(privateKey *rsa.PrivateKey, saltLength int, hash crypto.Hash) *RSASigner {
+	return &RSASigner{
+		privateKey: privateKey,
+		hash:       hash,
+		pss:        pssOptions(saltLength, hash),
+	}
 }
 
 func (s *RSASigner) Sign(data []byte) ([]byte, error) {
-	hash := sha256.Sum256(data)
-	return rsa.SignPKCS1v15(rand.Reader, s.privateKey, crypto.SHA256, hash[:])
+	hashed := digest(s.hash, data)
+	if s.pss != nil {
+		return rsa.SignPSS(rand.Reader, s.privateKey, s.hash, hashed, s.pss)
+	}
+
+	return rsa.SignPKCS1v15(rand.Reader, s.privateKey, s.hash, hashed)
 }
 
 type ECDSASigner struct {
 	privateKey *ecdsa.PrivateKey
+	hash       crypto.Hash
 }
 
-func NewECDSASigner(privateKey *ecdsa.PrivateKey) *ECDSASigner {
-	return &ECDSASigner{privateKey: privateKey}
+// NewECDSASigner creates a Signer that signs the digest of the data under the given hash.
+func NewECDSASigner(privateKey *ecdsa.PrivateKey, hash crypto.Hash) *ECDSASigner {
+	return &ECDSASigner{privateKey: privateKey, hash: hash}
 }
 
 func (s *ECDSASigner) Sign(data []byte) ([]byte, error) {
-	hash := sha256.Sum256(data)
-	return ecdsa.SignASN1(rand.Reader, s.privateKey, hash[:])
+	return ecdsa.SignASN1(rand.Reader, s.privateKey, digest(s.hash, data))
+}
+
+type Ed25519Signer struct {
+	privateKey ed25519.PrivateKey
+}
+
+func NewEd25519Signer(privateKey ed25519.PrivateKey) *Ed25519Signer {
+	return &Ed25519Signer{privateKey: privateKey}
+}
+
+// Sign signs the data itself, as Ed25519 hashes the message internally.
+func (s *Ed25519Signer) Sign(data []byte) ([]byte, error) {
+	return ed25519.Sign(s.privateKey, data), nil
 }
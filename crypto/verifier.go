@@ -0,0 +1,87 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+// Verifier defines a contract for checking signatures produced by a Signer.
+// Verify returns domain.ErrInvalidSignature if the signature does not match the data.
+type Verifier interface {
+	Verify(signedData []byte, signature []byte) error
+}
+
+type RSAVerifier struct {
+	publicKey *rsa.PublicKey
+	hash      crypto.Hash
+	pss       *rsa.PSSOptions
+}
+
+// NewRSAVerifier creates a Verifier for the RSASSA-PKCS1-v1_5 scheme over the given hash.
+func NewRSAVerifier(publicKey *rsa.PublicKey, hash crypto.Hash) *RSAVerifier {
+	return &RSAVerifier{publicKey: publicKey, hash: hash}
+}
+
+// NewRSAPSSVerifier creates a Verifier for the RSASSA-PSS scheme over the given hash.
+// A saltLength of zero uses the length of the hash.
+func NewRSAPSSVerifier(publicKey *rsa.PublicKey, saltLength int, hash crypto.Hash) *RSAVerifier {
+	return &RSAVerifier{
+		publicKey: publicKey,
+		hash:      hash,
+		pss:       pssOptions(saltLength, hash),
+	}
+}
+
+func (v *RSAVerifier) Verify(data []byte, signature []byte) error {
+	hashed := digest(v.hash, data)
+
+	var err error
+	if v.pss != nil {
+		err = rsa.VerifyPSS(v.publicKey, v.hash, hashed, signature, v.pss)
+	} else {
+		err = rsa.VerifyPKCS1v15(v.publicKey, v.hash, hashed, signature)
+	}
+	if err != nil {
+		return domain.ErrInvalidSignature
+	}
+
+	return nil
+}
+
+type ECDSAVerifier struct {
+	publicKey *ecdsa.PublicKey
+	hash      crypto.Hash
+}
+
+// NewECDSAVerifier creates a Verifier for signatures over the digest of the data under the given hash.
+func NewECDSAVerifier(publicKey *ecdsa.PublicKey, hash crypto.Hash) *ECDSAVerifier {
+	return &ECDSAVerifier{publicKey: publicKey, hash: hash}
+}
+
+func (v *ECDSAVerifier) Verify(data []byte, signature []byte) error {
+	if !ecdsa.VerifyASN1(v.publicKey, digest(v.hash, data), signature) {
+		return domain.ErrInvalidSignature
+	}
+
+	return nil
+}
+
+type Ed25519Verifier struct {
+	publicKey ed25519.PublicKey
+}
+
+func NewEd25519Verifier(publicKey ed25519.PublicKey) *Ed25519Verifier {
+	return &Ed25519Verifier{publicKey: publicKey}
+}
+
+func (v *Ed25519Verifier) Verify(data []byte, signature []byte) error {
+	if !ed25519.Verify(v.publicKey, data, signature) {
+		return domain.ErrInvalidSignature
+	}
+
+	return nil
+}
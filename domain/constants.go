@@ -1,6 +1,55 @@
 package domain
 
 const (
-	AlgorithmRSA = "RSA"
-	AlgorithmECC = "ECC"
+	AlgorithmRSA     = "RSA"
+	AlgorithmECC     = "ECC"
+	AlgorithmEd25519 = "ED25519"
 )
+
+// Supported RSA modulus sizes in bits.
+const (
+	RSAKeySize2048 = 2048
+	RSAKeySize3072 = 3072
+	RSAKeySize4096 = 4096
+)
+
+// Supported elliptic curves, named as in FIPS 186.
+const (
+	CurveP256 = "P-256"
+	CurveP384 = "P-384"
+	CurveP521 = "P-521"
+)
+
+// Supported RSA signature schemes.
+const (
+	SchemePKCS1v15 = "PKCS1v15"
+	SchemePSS      = "PSS"
+)
+
+// Hash algorithms the signed data of a device is digested with.
+const (
+	HashSHA256   = "SHA-256"
+	HashSHA384   = "SHA-384"
+	HashSHA512   = "SHA-512"
+	HashSHA3_256 = "SHA3-256"
+)
+
+// Lifecycle states of a device. Only active devices sign; decommissioning is final.
+const (
+	DeviceStatusActive         = "ACTIVE"
+	DeviceStatusDisabled       = "DISABLED"
+	DeviceStatusDecommissioned = "DECOMMISSIONED"
+)
+
+// KeyRolloverPrefix starts the data of the chain entry that links a new key of a device
+// into its signature chain: KEY_ROLLOVER:<new key version>:<new public key fingerprint>.
+// The entry is signed with the key being retired.
+const KeyRolloverPrefix = "KEY_ROLLOVER"
+
+// DigestPrefix starts the data of a chain entry that signs a digest computed by the client
+// instead of the data itself: DIGEST:<hash algorithm>:<lowercase hex digest>.
+const DigestPrefix = "DIGEST"
+
+// UploadPrefix starts the data of a chain entry that signs content uploaded as a stream:
+// UPLOAD:<hash algorithm>:<lowercase hex digest>:<size in bytes>.
+const UploadPrefix = "UPLOAD"
@@ -1,19 +1,140 @@
 package domain
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 type Device struct {
-	ID               string    `json:"id"`
-	Algorithm        string    `json:"algorithm"`
-	Label            string    `json:"label"`
-	SignatureCounter int       `json:"signatureCounter"`
-	LastSignature    string    `json:"-"`
-	PrivateKey       string    `json:"-"`
-	PublicKey        string    `json:"publicKey"`
-	CreatedAt        time.Time `json:"createdAt"`
+	ID               string            `json:"id"`
+	Algorithm        string            `json:"algorithm"`
+	KeySize          int               `json:"keySize,omitempty"`
+	Curve            string            `json:"curve,omitempty"`
+	SignatureScheme  string            `json:"signatureScheme,omitempty"`
+	PSSSaltLength    int               `json:"pssSaltLength,omitempty"`
+	HashAlgorithm    string            `json:"hashAlgorithm,omitempty"`
+	SignedDataFormat string            `json:"signedDataFormat,omitempty"`
+	Label            string            `json:"label"`
+	Metadata         map[string]string `json:"metadata,omitempty"`
+	SignatureCounter int               `json:"signatureCounter"`
+	LastSignature    string            `json:"-"`
+	KeyHandle        string            `json:"-"`
+	PrivateKey       string            `json:"-"`
+	PublicKey        string            `json:"publicKey"`
+	KeyVersion       int               `json:"keyVersion"`
+	KeyHistory       []DeviceKey       `json:"keyHistory,omitempty"`
+	Status           string            `json:"status"`
+	StatusHistory    []StatusChange    `json:"statusHistory,omitempty"`
+	CreatedAt        time.Time         `json:"createdAt"`
+	Version          int               `json:"version"`
 }
 
+// CurrentStatus returns the lifecycle state of the device. Devices stored before
+// lifecycle states were introduced have none and count as active.
+func (d *Device) CurrentStatus() string {
+	if d.Status == "" {
+		return DeviceStatusActive
+	}
+
+	return d.Status
+}
+
+// EffectiveSignedDataFormat returns the format the device encodes its signed data in.
+// Devices stored before the format could be chosen have none and use the legacy format.
+func (d *Device) EffectiveSignedDataFormat() string {
+	if d.SignedDataFormat == "" {
+		return SignedDataFormatLegacy
+	}
+
+	return d.SignedDataFormat
+}
+
+// Keys returns every key the device has signed with, oldest first. Devices stored before
+// key rotation was introduced have no history and only ever used their current key.
+func (d *Device) Keys() []DeviceKey {
+	if len(d.KeyHistory) > 0 {
+		return d.KeyHistory
+	}
+
+	return []DeviceKey{{
+		Version:          1,
+		PublicKey:        d.PublicKey,
+		ValidFromCounter: 0,
+		CreatedAt:        d.CreatedAt,
+	}}
+}
+
+// KeyForCounter returns the key the device signed the given counter with.
+func (d *Device) KeyForCounter(counter int) DeviceKey {
+	keys := d.Keys()
+	for i := len(keys) - 1; i > 0; i-- {
+		if counter >= keys[i].ValidFromCounter {
+			return keys[i]
+		}
+	}
+
+	return keys[0]
+}
+
+// KeyByVersion returns the key of the device with the given version.
+func (d *Device) KeyByVersion(version int) (DeviceKey, bool) {
+	for _, key := range d.Keys() {
+		if key.Version == version {
+			return key, true
+		}
+	}
+
+	return DeviceKey{}, false
+}
+
+// KeyID identifies a version of the key of a device, for instance as the kid of a JWK.
+// The first key is identified by the device ID alone, keys introduced by a rotation by
+// <device ID>:<version>, so the ID of a key never changes.
+func KeyID(deviceID string, version int) string {
+	if version <= 1 {
+		return deviceID
+	}
+
+	return fmt.Sprintf("%s:%d", deviceID, version)
+}
+
+// DeviceKey is a public key a device has signed with, together with the range of
+// signature counters it is valid for. ValidUntilCounter is inclusive and unset for the
+// current key.
+type DeviceKey struct {
+	Version           int        `json:"version"`
+	PublicKey         string     `json:"publicKey"`
+	ValidFromCounter  int        `json:"validFromCounter"`
+	ValidUntilCounter *int       `json:"validUntilCounter,omitempty"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	RetiredAt         *time.Time `json:"retiredAt,omitempty"`
+}
+
+// StatusChange records a transition of the lifecycle state of a device.
+type StatusChange struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Reason    string    `json:"reason,omitempty"`
+	ChangedAt time.Time `json:"changedAt"`
+}
+
+// SignatureResult is the receipt of a signed chain entry: what was signed, when, and with
+// which key and algorithms, so clients need not parse it out of the signed data.
 type SignatureResult struct {
-	Signature  string `json:"signature"`
-	SignedData string `json:"signedData"`
+	DeviceID          string    `json:"deviceId"`
+	Counter           int       `json:"counter"`
+	Algorithm         string    `json:"algorithm"`
+	SignatureScheme   string    `json:"signatureScheme,omitempty"`
+	HashAlgorithm     string    `json:"hashAlgorithm"`
+	KeyVersion        int       `json:"keyVersion"`
+	Timestamp         time.Time `json:"timestamp"`
+	PreviousSignature string    `json:"previousSignature"`
+	Signature         string    `json:"signature"`
+	SignedData        string    `json:"signedData"`
+}
+
+type VerificationResult struct {
+	DeviceID string       `json:"deviceId"`
+	Valid    bool         `json:"valid"`
+	Entry    *SignedEntry `json:"entry,omitempty"`
 }
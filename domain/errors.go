@@ -3,9 +3,46 @@ package domain
 import "errors"
 
 var (
-	ErrDeviceNotFound      = errors.New("device not found")
-	ErrDeviceAlreadyExists = errors.New("device already exists")
-	ErrInvalidAlgorithm    = errors.New("invalid algorithm")
-	ErrInvalidDeviceID     = errors.New("invalid device ID")
-	ErrEmptyData           = errors.New("data to sign cannot be empty")
+	ErrDeviceNotFound           = errors.New("device not found")
+	ErrDeviceAlreadyExists      = errors.New("device already exists")
+	ErrInvalidAlgorithm         = errors.New("invalid algorithm")
+	ErrInvalidKeyParameters     = errors.New("invalid key parameters for algorithm")
+	ErrInvalidSignatureScheme   = errors.New("invalid signature scheme for algorithm")
+	ErrInvalidSaltLength        = errors.New("invalid PSS salt length")
+	ErrInvalidDeviceID          = errors.New("invalid device ID")
+	ErrEmptyData                = errors.New("data to sign cannot be empty")
+	ErrTransactionNotFound      = errors.New("transaction not found")
+	ErrTransactionGap           = errors.New("transaction counter does not continue the chain")
+	ErrInvalidSignature         = errors.New("invalid signature")
+	ErrInvalidKeyEncoding       = errors.New("invalid key encoding")
+	ErrEmptySignedData          = errors.New("signed data cannot be empty")
+	ErrEmptySignature           = errors.New("signature cannot be empty")
+	ErrInvalidSignatureEncoding = errors.New("signature is not valid base64")
+	ErrInvalidMasterKey         = errors.New("master key must be 32 bytes encoded in base64")
+	ErrUnknownMasterKey         = errors.New("private key is wrapped under an unknown master key")
+	ErrInvalidWrappedKey        = errors.New("invalid wrapped private key")
+	ErrKeyNotFound              = errors.New("key not found in key store")
+	ErrDeviceNotActive          = errors.New("device is not active")
+	ErrInvalidStatusTransition  = errors.New("invalid device status transition")
+	ErrInvalidStatus            = errors.New("invalid device status")
+	ErrReservedData             = errors.New("data must not start with a reserved prefix")
+	ErrInvalidIdempotencyKey    = errors.New("idempotency key must be at most 255 characters")
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used with different data")
+	ErrEmptyBatch               = errors.New("batch must contain at least one item")
+	ErrBatchTooLarge            = errors.New("batch exceeds the maximum number of items")
+	ErrKeyVersionNotFound       = errors.New("key version not found")
+	ErrInvalidCursor            = errors.New("invalid pagination cursor")
+	ErrInvalidPageLimit         = errors.New("page limit must be between 1 and 500")
+	ErrInvalidSortField         = errors.New("devices can only be sorted by createdAt or signatureCounter")
+	ErrInvalidDateRange         = errors.New("createdAfter must not be later than createdBefore")
+	ErrInvalidLabel             = errors.New("label must be at most 255 characters")
+	ErrInvalidMetadata          = errors.New("metadata must have at most 32 entries with keys of 1 to 64 and values of at most 256 characters")
+	ErrEmptyUpdate              = errors.New("update must change the label or the metadata")
+	ErrVersionMismatch          = errors.New("device has been modified since the given version")
+	ErrInvalidSignedData        = errors.New("signed data is not in a known format")
+	ErrInvalidSignedDataFormat  = errors.New("signed data format must be LEGACY or V1")
+	ErrInvalidHashAlgorithm     = errors.New("invalid hash algorithm for algorithm")
+	ErrInvalidDigestAlgorithm   = errors.New("digest hash algorithm must be SHA-256, SHA-384, SHA-512 or SHA3-256")
+	ErrInvalidDigest            = errors.New("digest must be hex or base64 and as long as the output of its hash algorithm")
+	ErrUploadFailed             = errors.New("upload could not be read")
 )
@@ -0,0 +1,45 @@
+package domain
+
+import "time"
+
+// Fields devices can be sorted by.
+const (
+	DeviceSortCreatedAt        = "createdAt"
+	DeviceSortSignatureCounter = "signatureCounter"
+)
+
+// Page sizes of device listings.
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 500
+)
+
+// DeviceQuery selects, orders and pages devices. Devices are ordered by SortBy and then
+// by ID, which makes the order total and lets a listing resume after any device.
+type DeviceQuery struct {
+	// Algorithm keeps devices of this algorithm only.
+	Algorithm string
+	// LabelContains keeps devices whose label contains this text, ignoring case.
+	LabelContains string
+	// CreatedAfter keeps devices created at or after this time.
+	CreatedAfter *time.Time
+	// CreatedBefore keeps devices created before this time.
+	CreatedBefore *time.Time
+	// Metadata keeps devices that have all of these metadata entries.
+	Metadata map[string]string
+
+	SortBy     string
+	Descending bool
+
+	// Limit is the maximum number of devices in the page.
+	Limit int
+	// Cursor resumes a listing after the last device of the previous page. It is the
+	// NextCursor of that page and only valid with the same filters and order.
+	Cursor string
+}
+
+// DevicePage is one page of a device listing. NextCursor is empty on the last page.
+type DevicePage struct {
+	Devices    []*Device `json:"devices"`
+	NextCursor string    `json:"nextCursor,omitempty"`
+}
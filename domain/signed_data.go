@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Encodings of the signed data of a chain entry. Devices without a format predate the
+// choice and sign in the legacy format.
+const (
+	// SignedDataFormatLegacy concatenates <counter>_<data>_<previous signature>.
+	SignedDataFormatLegacy = "LEGACY"
+	// SignedDataFormatV1 starts with "v1:", followed by the counter, device ID, RFC 3339
+	// timestamp (UTC), data and previous signature, each encoded as <byte length>:<value>,
+	// for instance v1:1:7,36:<device ID>,20:2025-10-26T07:00:00Z,4:SALE,43:<signature>,
+	SignedDataFormatV1 = "V1"
+)
+
+const signedDataV1Prefix = "v1:"
+
+// SignedEntry is the content of the signed data of a chain entry. DeviceID and Timestamp
+// are only part of the V1 format.
+type SignedEntry struct {
+	Format            string     `json:"format"`
+	Counter           int        `json:"counter"`
+	DeviceID          string     `json:"deviceId,omitempty"`
+	Timestamp         *time.Time `json:"timestamp,omitempty"`
+	Data              string     `json:"data"`
+	PreviousSignature string     `json:"previousSignature"`
+}
+
+// Encode returns the signed data of the entry in its format.
+func (e SignedEntry) Encode() string {
+	if e.Format != SignedDataFormatV1 {
+		return fmt.Sprintf("%d_%s_%s", e.Counter, e.Data, e.PreviousSignature)
+	}
+
+	var timestamp string
+	if e.Timestamp != nil {
+		timestamp = e.Timestamp.UTC().Format(time.RFC3339Nano)
+	}
+
+	var b strings.Builder
+	b.WriteString(signedDataV1Prefix)
+	for _, field := range []string{strconv.Itoa(e.Counter), e.DeviceID, timestamp, e.Data, e.PreviousSignature} {
+		b.WriteString(strconv.Itoa(len(field)))
+		b.WriteByte(':')
+		b.WriteString(field)
+		b.WriteByte(',')
+	}
+
+	return b.String()
+}
+
+// ParseSignedData decomposes signed data of either format. Legacy data is split at the
+// first and the last underscore, which relies on neither the counter nor the base64
+// previous signature containing one. V1 data has to be in its canonical encoding.
+func ParseSignedData(signedData string) (*SignedEntry, error) {
+	if strings.HasPrefix(signedData, signedDataV1Prefix) {
+		return parseSignedDataV1(signedData)
+	}
+
+	counterPart, rest, found := strings.Cut(signedData, "_")
+	if !found {
+		return nil, ErrInvalidSignedData
+	}
+
+	separator := strings.LastIndex(rest, "_")
+	if separator < 0 {
+		return nil, ErrInvalidSignedData
+	}
+
+	counter, err := parseCounter(counterPart)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignedEntry{
+		Format:            SignedDataFormatLegacy,
+		Counter:           counter,
+		Data:              rest[:separator],
+		PreviousSignature: rest[separator+1:],
+	}, nil
+}
+
+func parseSignedDataV1(signedData string) (*SignedEntry, error) {
+	rest := strings.TrimPrefix(signedData, signedDataV1Prefix)
+
+	fields := make([]string, 0, 5)
+	for len(fields) < 5 {
+		lengthPart, value, found := strings.Cut(rest, ":")
+		if !found {
+			return nil, ErrInvalidSignedData
+		}
+
+		length, err := strconv.Atoi(lengthPart)
+		if err != nil || length < 0 || length >= len(value) || value[length] != ',' {
+			return nil, ErrInvalidSignedData
+		}
+
+		fields = append(fields, value[:length])
+		rest = value[length+1:]
+	}
+
+	counter, err := parseCounter(fields[0])
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, err := time.Parse(time.RFC3339Nano, fields[2])
+	if err != nil {
+		return nil, ErrInvalidSignedData
+	}
+
+	entry := &SignedEntry{
+		Format:            SignedDataFormatV1,
+		Counter:           counter,
+		DeviceID:          fields[1],
+		Timestamp:         &timestamp,
+		Data:              fields[3],
+		PreviousSignature: fields[4],
+	}
+
+	// only the canonical encoding of an entry is valid, so an entry has exactly one
+	if entry.Encode() != signedData {
+		return nil, ErrInvalidSignedData
+	}
+
+	return entry, nil
+}
+
+func parseCounter(s string) (int, error) {
+	counter, err := strconv.Atoi(s)
+	if err != nil || counter < 0 || strconv.Itoa(counter) != s {
+		return 0, ErrInvalidSignedData
+	}
+
+	return counter, nil
+}
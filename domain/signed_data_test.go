@@ -0,0 +1,63 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignedEntry_Encode(t *testing.T) {
+	timestamp := time.Date(2025, 10, 26, 9, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	legacy := domain.SignedEntry{
+		Format:            domain.SignedDataFormatLegacy,
+		Counter:           7,
+		DeviceID:          "dev",
+		Timestamp:         &timestamp,
+		Data:              "SALE_100",
+		PreviousSignature: "sig",
+	}
+	assert.Equal(t, "7_SALE_100_sig", legacy.Encode(), "the legacy format carries no device ID or timestamp")
+
+	v1 := legacy
+	v1.Format = domain.SignedDataFormatV1
+	assert.Equal(t, "v1:1:7,3:dev,20:2025-10-26T07:00:00Z,8:SALE_100,3:sig,", v1.Encode(), "timestamps are encoded in UTC")
+}
+
+func TestParseSignedData(t *testing.T) {
+	legacy, err := domain.ParseSignedData("7_SALE_100_sig")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.SignedDataFormatLegacy, legacy.Format)
+	assert.Equal(t, 7, legacy.Counter)
+	assert.Equal(t, "SALE_100", legacy.Data, "legacy data keeps its separators")
+	assert.Equal(t, "sig", legacy.PreviousSignature)
+	assert.Nil(t, legacy.Timestamp)
+
+	valid := "v1:1:7,3:dev,20:2025-10-26T07:00:00Z,4:SALE,3:sig,"
+
+	entry, err := domain.ParseSignedData(valid)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, entry.Counter)
+	assert.Equal(t, "dev", entry.DeviceID)
+	assert.Equal(t, "SALE", entry.Data)
+	assert.Equal(t, "sig", entry.PreviousSignature)
+	assert.Equal(t, "2025-10-26T07:00:00Z", entry.Timestamp.Format("2006-01-02T15:04:05Z07:00"))
+
+	for _, signedData := range []string{
+		"",
+		"SALE",
+		"x_SALE_sig",
+		"07_SALE_sig",
+		"v1:1:7,3:dev,20:2025-10-26T07:00:00Z,4:SALE,3:sig",
+		"v1:1:7,3:dev,20:2025-10-26T07:00:00Z,4:SALE,3:sig,trailing",
+		"v1:1:7,3:dev,20:2025-10-26T07:00:00Z,5:SALE,3:sig,",
+		"v1:2:07,3:dev,20:2025-10-26T07:00:00Z,4:SALE,3:sig,",
+		"v1:1:7,3:dev,25:2025-10-26T09:00:00+02:00,4:SALE,3:sig,",
+		"v1:1:7,3:dev,9:yesterday,4:SALE,3:sig,",
+	} {
+		_, err := domain.ParseSignedData(signedData)
+		assert.ErrorIs(t, err, domain.ErrInvalidSignedData, signedData)
+	}
+}
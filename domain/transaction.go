@@ -0,0 +1,38 @@
+package domain
+
+import "time"
+
+// Transaction is a single entry of a device's signature chain, together with the receipt
+// details it was signed with. Entries recorded before receipts were kept lack them.
+type Transaction struct {
+	DeviceID          string    `json:"deviceId"`
+	Counter           int       `json:"counter"`
+	Data              string    `json:"data"`
+	SignedData        string    `json:"signedData"`
+	Signature         string    `json:"signature"`
+	Algorithm         string    `json:"algorithm,omitempty"`
+	SignatureScheme   string    `json:"signatureScheme,omitempty"`
+	HashAlgorithm     string    `json:"hashAlgorithm,omitempty"`
+	KeyVersion        int       `json:"keyVersion,omitempty"`
+	PreviousSignature string    `json:"previousSignature,omitempty"`
+	CreatedAt         time.Time `json:"createdAt"`
+
+	// IdempotencyKey is the key the client sent with the signing request, answered with
+	// this entry until IdempotencyExpiresAt.
+	IdempotencyKey       string    `json:"-"`
+	IdempotencyExpiresAt time.Time `json:"-"`
+}
+
+// AuditReport is the outcome of verifying the signature chain of a device end to end.
+type AuditReport struct {
+	DeviceID            string      `json:"deviceId"`
+	Valid               bool        `json:"valid"`
+	TransactionsChecked int         `json:"transactionsChecked"`
+	BrokenLink          *ChainBreak `json:"brokenLink,omitempty"`
+}
+
+// ChainBreak describes the first link of a signature chain that failed the audit.
+type ChainBreak struct {
+	Counter int    `json:"counter"`
+	Reason  string `json:"reason"`
+}
@@ -0,0 +1,20 @@
+package domain
+
+// Limits of the descriptive attributes of a device.
+const (
+	MaxLabelLength         = 255
+	MaxMetadataEntries     = 32
+	MaxMetadataKeyLength   = 64
+	MaxMetadataValueLength = 256
+)
+
+// DeviceUpdate changes the descriptive attributes of a device. Attributes left nil are
+// kept. Metadata is merged into the metadata of the device: a key with a nil value is
+// removed, any other key is set.
+type DeviceUpdate struct {
+	Label    *string
+	Metadata map[string]*string
+
+	// IfVersion makes the update conditional on the device still having this version.
+	IfVersion *int
+}
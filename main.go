@@ -1,26 +1,222 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 
 	"github.com/fiskaly/coding-challenges/signing-service-challenge/api"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
 	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
 	"github.com/fiskaly/coding-challenges/signing-service-challenge/service"
 )
 
 const (
 	ListenAddress = ":8080"
+
+	StorageMemory = "memory"
+	StorageFile   = "file"
+
+	// MasterKeyEnv holds the base64 encoded master key and takes precedence over key files.
+	MasterKeyEnv = "SIGNING_SERVICE_MASTER_KEY"
+
+	masterKeyFileName = "master.key"
+	keyStoreDirName   = "keys"
+
+	rotateMasterKeyCommand = "rotate-master-key"
 )
 
 func main() {
-	repository := persistence.NewInMemoryRepository()
+	if len(os.Args) > 1 && os.Args[1] == rotateMasterKeyCommand {
+		rotateMasterKey(os.Args[2:])
+		return
+	}
+
+	storage := flag.String("storage", StorageMemory, "storage backend: memory or file")
+	dataDir := flag.String("data-dir", "data", "data directory of the file storage backend")
+	masterKeyFile := flag.String("master-key-file", "", "file with the base64 encoded master key (default: <data-dir>/master.key with file storage)")
+	previousMasterKeyFile := flag.String("previous-master-key-file", "", "file with a previous master key, still accepted for keys not yet re-wrapped")
+	flag.Parse()
 
-	deviceService := service.NewDeviceService(repository)
+	repository, transactions, err := newStorage(*storage, *dataDir)
+	if err != nil {
+		log.Fatal("Could not open storage: ", err)
+	}
+
+	keyEncrypter, err := newKeyEncrypter(*storage, *dataDir, *masterKeyFile, *previousMasterKeyFile)
+	if err != nil {
+		log.Fatal("Could not load master key: ", err)
+	}
+
+	keyStore, err := newKeyStore(*storage, *dataDir, keyEncrypter)
+	if err != nil {
+		log.Fatal("Could not open key store: ", err)
+	}
+
+	deviceService := service.NewDeviceService(
+		repository,
+		transactions,
+		service.WithKeyEncrypter(keyEncrypter),
+		service.WithKeyStore(keyStore),
+	)
 
 	server := api.NewServer(ListenAddress, deviceService)
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	log.Println("Server starting on port: ", ListenAddress)
-	if err := server.Run(); err != nil {
-		log.Fatal("Could not start server on ", ListenAddress)
+	if err := server.Run(ctx); err != nil {
+		log.Fatal("Could not start server on ", ListenAddress, ": ", err)
+	}
+
+	// closing the file storage compacts the device log into a snapshot
+	log.Println("Server stopped, closing storage")
+	if err := closeStorage(repository, transactions); err != nil {
+		log.Fatal("Could not close storage: ", err)
+	}
+}
+
+// closeStorage releases the repositories of storage backends that hold files open.
+func closeStorage(repository persistence.Repository, transactions persistence.TransactionRepository) error {
+	var errs []error
+	for _, store := range []interface{}{repository, transactions} {
+		if closer, ok := store.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// rotateMasterKey re-wraps the private keys of the key store and of legacy devices in a file
+// storage under a new master key.
+// The server must be stopped while the keys are re-wrapped.
+func rotateMasterKey(args []string) {
+	flags := flag.NewFlagSet(rotateMasterKeyCommand, flag.ExitOnError)
+	dataDir := flags.String("data-dir", "data", "data directory of the file storage backend")
+	masterKeyFile := flags.String("master-key-file", "", "file with the current master key (default: <data-dir>/master.key)")
+	newMasterKeyFile := flags.String("new-master-key-file", "", "file with the new master key, generated if it does not exist")
+	flags.Parse(args)
+
+	if *newMasterKeyFile == "" {
+		log.Fatal("Missing -new-master-key-file")
+	}
+	if *masterKeyFile == "" {
+		*masterKeyFile = filepath.Join(*dataDir, masterKeyFileName)
+	}
+
+	currentMasterKey, err := crypto.LoadMasterKeyFile(*masterKeyFile, false)
+	if err != nil {
+		log.Fatal("Could not load master key: ", err)
+	}
+
+	newMasterKey, err := crypto.LoadMasterKeyFile(*newMasterKeyFile, true)
+	if err != nil {
+		log.Fatal("Could not load new master key: ", err)
+	}
+
+	keyEncrypter, err := crypto.NewKeyEncrypter(newMasterKey, currentMasterKey)
+	if err != nil {
+		log.Fatal("Could not load master keys: ", err)
 	}
+
+	repository, err := persistence.NewFileRepository(*dataDir)
+	if err != nil {
+		log.Fatal("Could not open storage: ", err)
+	}
+
+	keyStore, err := crypto.NewFileKeyStore(filepath.Join(*dataDir, keyStoreDirName), keyEncrypter)
+	if err != nil {
+		log.Fatal("Could not open key store: ", err)
+	}
+
+	rewrappedKeys, err := keyStore.Rewrap()
+	if err != nil {
+		log.Fatal("Could not re-wrap key store: ", err)
+	}
+
+	rewrappedDevices, err := service.RewrapPrivateKeys(repository, keyEncrypter)
+	if closeErr := repository.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Fatal("Could not re-wrap private keys: ", err)
+	}
+
+	log.Printf("Re-wrapped %d private keys. Start the server with -master-key-file %s",
+		rewrappedKeys+rewrappedDevices, *newMasterKeyFile)
+}
+
+// newStorage instantiates the device and transaction repositories for the selected storage backend.
+func newStorage(storage string, dataDir string) (persistence.Repository, persistence.TransactionRepository, error) {
+	switch storage {
+	case StorageMemory:
+		return persistence.NewInMemoryRepository(), persistence.NewInMemoryTransactionRepository(), nil
+	case StorageFile:
+		log.Println("Using file storage in: ", dataDir)
+		repository, err := persistence.NewFileRepository(dataDir)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		transactions, err := persistence.NewFileTransactionRepository(dataDir)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return repository, transactions, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown storage backend %q", storage)
+	}
+}
+
+// newKeyStore instantiates the key store matching the storage backend: keys of devices in a
+// file storage live in a soft-HSM next to it, keys of in-memory devices in process memory.
+func newKeyStore(storage string, dataDir string, keyEncrypter *crypto.KeyEncrypter) (crypto.KeyStore, error) {
+	if storage == StorageFile {
+		return crypto.NewFileKeyStore(filepath.Join(dataDir, keyStoreDirName), keyEncrypter)
+	}
+
+	return crypto.NewSoftwareKeyStore(), nil
+}
+
+// newKeyEncrypter loads the master key from the environment or a key file. File storage falls
+// back to a key file in the data directory, created on first start, while memory storage
+// uses an ephemeral master key.
+func newKeyEncrypter(storage string, dataDir string, masterKeyFile string, previousMasterKeyFile string) (*crypto.KeyEncrypter, error) {
+	var masterKey []byte
+	var err error
+
+	switch {
+	case os.Getenv(MasterKeyEnv) != "":
+		masterKey, err = crypto.DecodeMasterKey(os.Getenv(MasterKeyEnv))
+	case masterKeyFile != "":
+		masterKey, err = crypto.LoadMasterKeyFile(masterKeyFile, false)
+	case storage == StorageFile:
+		masterKey, err = crypto.LoadMasterKeyFile(filepath.Join(dataDir, masterKeyFileName), true)
+	default:
+		return crypto.NewEphemeralKeyEncrypter(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if previousMasterKeyFile == "" {
+		return crypto.NewKeyEncrypter(masterKey)
+	}
+
+	previousMasterKey, err := crypto.LoadMasterKeyFile(previousMasterKeyFile, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return crypto.NewKeyEncrypter(masterKey, previousMasterKey)
 }
@@ -0,0 +1,85 @@
+package persistence_test
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
+)
+
+// signingWork stands in for the signature computed inside an update.
+func signingWork() {
+	digest := sha256.Sum256([]byte("transaction"))
+	for i := 0; i < 2000; i++ {
+		digest = sha256.Sum256(digest[:])
+	}
+}
+
+// BenchmarkInMemoryRepository_Update updates devices from parallel goroutines, each
+// working on a device of its own or all sharing a single device. With per-device locks
+// the former scales with GOMAXPROCS while the latter stays sequential.
+func BenchmarkInMemoryRepository_Update(b *testing.B) {
+	for _, devices := range []int{1, 64} {
+		b.Run(fmt.Sprintf("devices=%d", devices), func(b *testing.B) {
+			r := persistence.NewInMemoryRepository()
+			for i := 0; i < devices; i++ {
+				if err := r.Create(&domain.Device{ID: fmt.Sprint(i), Algorithm: "ECC"}); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			var next atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				id := fmt.Sprint(next.Add(1) % int64(devices))
+				for pb.Next() {
+					_, err := r.Update(id, func(device *domain.Device) error {
+						signingWork()
+						device.SignatureCounter++
+						return nil
+					})
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkInMemoryRepository_GetByID reads a device while other goroutines keep it busy.
+func BenchmarkInMemoryRepository_GetByID(b *testing.B) {
+	r := persistence.NewInMemoryRepository()
+	if err := r.Create(&domain.Device{ID: "1", Algorithm: "ECC"}); err != nil {
+		b.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				_, _ = r.Update("1", func(device *domain.Device) error {
+					signingWork()
+					device.SignatureCounter++
+					return nil
+				})
+			}
+		}
+	}()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := r.GetByID("1"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,28 @@
+package persistence
+
+import (
+	"sync"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+// deviceEntry is a stored device together with the lock that serialises its updates.
+// Holding the lock of one device while its update function runs, for instance while a
+// transaction is being signed, leaves every other device free to be read and updated.
+type deviceEntry struct {
+	update sync.Mutex
+	device *domain.Device
+}
+
+func newDeviceEntry(device *domain.Device) *deviceEntry {
+	return &deviceEntry{
+		update: sync.Mutex{},
+		device: device,
+	}
+}
+
+// snapshotOf returns a copy of the device that later updates do not affect.
+func snapshotOf(device *domain.Device) *domain.Device {
+	copied := *device
+	return &copied
+}
@@ -0,0 +1,40 @@
+package persistence
+
+import "errors"
+
+// errInjected is the error of a write failed by FailNextLogWrite.
+var errInjected = errors.New("injected write failure")
+
+// faultyFile writes only the first n bytes of the next write and fails it, as a full
+// disk would.
+type faultyFile struct {
+	logFile
+	n       int
+	pending bool
+	// truncate makes Truncate fail as well, so the torn frame cannot be cut off
+	truncate bool
+}
+
+func (f *faultyFile) Write(p []byte) (int, error) {
+	if !f.pending {
+		return f.logFile.Write(p)
+	}
+
+	f.pending = false
+	written, _ := f.logFile.Write(p[:f.n])
+	return written, errInjected
+}
+
+func (f *faultyFile) Truncate(size int64) error {
+	if f.truncate {
+		return errInjected
+	}
+
+	return f.logFile.Truncate(size)
+}
+
+// FailNextLogWrite makes the next write to the device log of r persist only n bytes
+// and fail. If truncate is set, cutting off the torn frame fails too.
+func FailNextLogWrite(r *FileRepository, n int, truncate bool) {
+	r.log.file = &faultyFile{logFile: r.log.file, n: n, pending: true, truncate: truncate}
+}
@@ -0,0 +1,281 @@
+package persistence
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+const (
+	deviceLogFileName      = "devices.wal"
+	deviceSnapshotFileName = "devices.snapshot"
+
+	// DefaultSnapshotInterval is the number of log records after which the
+	// device log is compacted into a snapshot.
+	DefaultSnapshotInterval = 1000
+)
+
+// FileRepository is a durable Repository backed by a data directory.
+// Every mutation is appended to a write-ahead log and synced before it becomes
+// visible, and the log is periodically compacted into a snapshot. As in the
+// InMemoryRepository, updates are serialised per device; the repository-wide lock
+// is only held to write the log and publish the result.
+type FileRepository struct {
+	mu      sync.RWMutex
+	devices map[string]*deviceEntry
+
+	dir              string
+	log              *writeAheadLog
+	pending          int
+	snapshotInterval int
+}
+
+// NewFileRepository opens or creates a FileRepository in dir and restores the
+// devices from the latest snapshot and the write-ahead log.
+func NewFileRepository(dir string) (*FileRepository, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	r := &FileRepository{
+		mu:               sync.RWMutex{},
+		devices:          make(map[string]*deviceEntry),
+		dir:              dir,
+		snapshotInterval: DefaultSnapshotInterval,
+	}
+
+	if err := r.loadSnapshot(); err != nil {
+		return nil, err
+	}
+
+	log, err := openWriteAheadLog(filepath.Join(dir, deviceLogFileName), func(payload []byte) error {
+		device, err := decodeDevice(payload)
+		if err != nil {
+			return err
+		}
+
+		r.restore(device)
+		r.pending++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.log = log
+
+	return r, nil
+}
+
+// SetSnapshotInterval changes the number of log records after which the log is compacted.
+func (r *FileRepository) SetSnapshotInterval(interval int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.snapshotInterval = interval
+}
+
+func (r *FileRepository) Create(device *domain.Device) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.devices[device.ID]; exists {
+		return domain.ErrDeviceAlreadyExists
+	}
+
+	if err := r.persist(device); err != nil {
+		return err
+	}
+
+	r.devices[device.ID] = newDeviceEntry(snapshotOf(device))
+	r.compact()
+	return nil
+}
+
+func (r *FileRepository) GetByID(id string) (*domain.Device, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, exists := r.devices[id]
+	if !exists {
+		return nil, domain.ErrDeviceNotFound
+	}
+
+	return snapshotOf(entry.device), nil
+}
+
+func (r *FileRepository) FindAll() ([]*domain.Device, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	devices := make([]*domain.Device, 0)
+	for _, entry := range r.devices {
+		devices = append(devices, snapshotOf(entry.device))
+	}
+
+	return devices, nil
+}
+
+func (r *FileRepository) Query(query domain.DeviceQuery) (*domain.DevicePage, error) {
+	devices, err := r.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return queryDevices(devices, query)
+}
+
+// Update applies updateFn to a copy of the device while holding the lock of that
+// device only, and makes the result visible once it has been written to the log.
+// If updateFn or the write fails, the stored device is left untouched.
+func (r *FileRepository) Update(deviceID string, updateFn func(*domain.Device) error) (*domain.Device, error) {
+	r.mu.RLock()
+	entry, exists := r.devices[deviceID]
+	r.mu.RUnlock()
+	if !exists {
+		return nil, domain.ErrDeviceNotFound
+	}
+
+	entry.update.Lock()
+	defer entry.update.Unlock()
+
+	updated := snapshotOf(entry.device)
+	if err := updateFn(updated); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.persist(updated); err != nil {
+		return nil, err
+	}
+
+	*entry.device = *updated
+	r.compact()
+	return updated, nil
+}
+
+// Snapshot writes all devices to the snapshot file and truncates the log.
+func (r *FileRepository) Snapshot() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.snapshot()
+}
+
+// Close compacts the log into a snapshot and releases the log file.
+func (r *FileRepository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshotErr := r.snapshot()
+	closeErr := r.log.Close()
+
+	return errors.Join(snapshotErr, closeErr)
+}
+
+// persist appends the device to the log. Must be called with the write lock held.
+func (r *FileRepository) persist(device *domain.Device) error {
+	payload, err := encodeDevice(device)
+	if err != nil {
+		return err
+	}
+
+	if err := r.log.Append(payload); err != nil {
+		return err
+	}
+
+	r.pending++
+	return nil
+}
+
+// compact writes a snapshot once the log has grown past the snapshot interval. It must
+// be called with the write lock held and only after the persisted device has been
+// published, or the snapshot would miss the record it truncates from the log.
+func (r *FileRepository) compact() {
+	if r.pending >= r.snapshotInterval {
+		// The records are already durable in the log, so a failed compaction
+		// is retried on the next write instead of failing this one.
+		_ = r.snapshot()
+	}
+}
+
+// snapshot must be called with the write lock held.
+func (r *FileRepository) snapshot() error {
+	devices := make([]domain.Device, 0, len(r.devices))
+	for _, entry := range r.devices {
+		devices = append(devices, *entry.device)
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(devices); err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(filepath.Join(r.dir, deviceSnapshotFileName), buf.Bytes()); err != nil {
+		return err
+	}
+
+	if err := r.log.Reset(); err != nil {
+		return err
+	}
+
+	r.pending = 0
+	return nil
+}
+
+func (r *FileRepository) loadSnapshot() error {
+	data, err := os.ReadFile(filepath.Join(r.dir, deviceSnapshotFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var devices []domain.Device
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&devices); err != nil {
+		return err
+	}
+
+	for i := range devices {
+		r.restore(&devices[i])
+	}
+
+	return nil
+}
+
+// restore places a device read from the snapshot or the log, replacing an older state.
+func (r *FileRepository) restore(device *domain.Device) {
+	if entry, exists := r.devices[device.ID]; exists {
+		entry.device = device
+		return
+	}
+
+	r.devices[device.ID] = newDeviceEntry(device)
+}
+
+// encodeDevice serialises the full device, including the fields hidden from
+// the API such as the private key and the last signature.
+func encodeDevice(device *domain.Device) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(device); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func decodeDevice(payload []byte) (*domain.Device, error) {
+	var device domain.Device
+	if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&device); err != nil {
+		return nil, err
+	}
+
+	return &device, nil
+}
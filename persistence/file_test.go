@@ -0,0 +1,282 @@
+package persistence_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileRepository_Create(t *testing.T) {
+	t.Run("sequential creates", func(t *testing.T) {
+		r, err := persistence.NewFileRepository(t.TempDir())
+		assert.NoError(t, err)
+		defer r.Close()
+
+		device := &domain.Device{
+			ID:        "1",
+			Algorithm: "RSA",
+			Label:     "Test Device",
+			CreatedAt: time.Now(),
+		}
+
+		assert.NoError(t, r.Create(device))
+
+		gotErr := r.Create(&domain.Device{ID: "1", Algorithm: "ECC"})
+		assert.Error(t, gotErr)
+		assert.EqualError(t, gotErr, domain.ErrDeviceAlreadyExists.Error())
+	})
+
+	t.Run("stored device is a copy", func(t *testing.T) {
+		r, err := persistence.NewFileRepository(t.TempDir())
+		assert.NoError(t, err)
+		defer r.Close()
+
+		device := &domain.Device{ID: "1", Algorithm: "RSA", Label: "Test Device"}
+		assert.NoError(t, r.Create(device))
+
+		// run with -race: updates must not write to the device of the caller,
+		// which the API still reads to answer the create request
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				_, err := r.Update("1", func(device *domain.Device) error {
+					device.SignatureCounter++
+					device.LastSignature = "Updated Signature"
+					return nil
+				})
+				assert.NoError(t, err)
+			}
+		}()
+
+		for i := 0; i < 100; i++ {
+			assert.Equal(t, 0, device.SignatureCounter)
+			assert.Equal(t, "", device.LastSignature)
+		}
+		wg.Wait()
+
+		got, err := r.GetByID("1")
+		assert.NoError(t, err)
+		assert.Equal(t, 100, got.SignatureCounter)
+		assert.Equal(t, 0, device.SignatureCounter)
+	})
+}
+
+func TestFileRepository_Restore(t *testing.T) {
+	t.Run("restore devices from the log", func(t *testing.T) {
+		dir := t.TempDir()
+
+		r, err := persistence.NewFileRepository(dir)
+		assert.NoError(t, err)
+
+		device := &domain.Device{
+			ID:            "1",
+			Algorithm:     "ECC",
+			Label:         "Test Device",
+			LastSignature: "MQ",
+			PrivateKey:    "private",
+			PublicKey:     "public",
+			CreatedAt:     time.Now().UTC(),
+		}
+		assert.NoError(t, r.Create(device))
+
+		_, err = r.Update(device.ID, func(device *domain.Device) error {
+			device.SignatureCounter++
+			device.LastSignature = "Updated Signature"
+			return nil
+		})
+		assert.NoError(t, err)
+
+		// simulate a crash: the log is not compacted into a snapshot
+		reopened, err := persistence.NewFileRepository(dir)
+		assert.NoError(t, err)
+
+		got, err := reopened.GetByID(device.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, got.SignatureCounter)
+		assert.Equal(t, "Updated Signature", got.LastSignature)
+		assert.Equal(t, "private", got.PrivateKey)
+		assert.Equal(t, "public", got.PublicKey)
+		assert.True(t, device.CreatedAt.Equal(got.CreatedAt))
+	})
+
+	t.Run("restore devices from snapshot and log", func(t *testing.T) {
+		dir := t.TempDir()
+
+		r, err := persistence.NewFileRepository(dir)
+		assert.NoError(t, err)
+		r.SetSnapshotInterval(2)
+
+		for _, id := range []string{"1", "2", "3"} {
+			assert.NoError(t, r.Create(&domain.Device{ID: id, Algorithm: "RSA"}))
+		}
+		assert.NoError(t, r.Close())
+
+		reopened, err := persistence.NewFileRepository(dir)
+		assert.NoError(t, err)
+		defer reopened.Close()
+
+		got, err := reopened.FindAll()
+		assert.NoError(t, err)
+		assert.Equal(t, 3, len(got))
+	})
+
+	t.Run("compaction keeps the update that triggered it", func(t *testing.T) {
+		dir := t.TempDir()
+
+		r, err := persistence.NewFileRepository(dir)
+		assert.NoError(t, err)
+		r.SetSnapshotInterval(2)
+
+		assert.NoError(t, r.Create(&domain.Device{ID: "1", Algorithm: "RSA"}))
+		_, err = r.Update("1", func(device *domain.Device) error {
+			device.SignatureCounter++
+			return nil
+		})
+		assert.NoError(t, err)
+
+		// simulate a crash right after the compaction
+		reopened, err := persistence.NewFileRepository(dir)
+		assert.NoError(t, err)
+
+		got, err := reopened.GetByID("1")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, got.SignatureCounter)
+	})
+
+	t.Run("discard torn record at the tail of the log", func(t *testing.T) {
+		dir := t.TempDir()
+
+		r, err := persistence.NewFileRepository(dir)
+		assert.NoError(t, err)
+		assert.NoError(t, r.Create(&domain.Device{ID: "1", Algorithm: "RSA"}))
+
+		// append half a record header as a crash in the middle of a write would
+		f, err := os.OpenFile(filepath.Join(dir, "devices.wal"), os.O_APPEND|os.O_WRONLY, 0o600)
+		assert.NoError(t, err)
+		_, err = f.Write([]byte{0, 0, 1})
+		assert.NoError(t, err)
+		assert.NoError(t, f.Close())
+
+		reopened, err := persistence.NewFileRepository(dir)
+		assert.NoError(t, err)
+
+		_, err = reopened.GetByID("1")
+		assert.NoError(t, err)
+
+		// the repository stays writable after the torn record has been discarded
+		assert.NoError(t, reopened.Create(&domain.Device{ID: "2", Algorithm: "RSA"}))
+
+		again, err := persistence.NewFileRepository(dir)
+		assert.NoError(t, err)
+
+		got, err := again.FindAll()
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(got))
+	})
+
+	t.Run("failed write does not hide later records", func(t *testing.T) {
+		dir := t.TempDir()
+
+		r, err := persistence.NewFileRepository(dir)
+		assert.NoError(t, err)
+		assert.NoError(t, r.Create(&domain.Device{ID: "1", Algorithm: "RSA"}))
+
+		// a partial frame reaches the file before the write fails
+		persistence.FailNextLogWrite(r, 5, false)
+		_, err = r.Update("1", func(device *domain.Device) error {
+			device.SignatureCounter = 1
+			return nil
+		})
+		assert.Error(t, err)
+
+		_, err = r.Update("1", func(device *domain.Device) error {
+			device.SignatureCounter = 2
+			return nil
+		})
+		assert.NoError(t, err)
+
+		reopened, err := persistence.NewFileRepository(dir)
+		assert.NoError(t, err)
+
+		got, err := reopened.GetByID("1")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, got.SignatureCounter)
+	})
+
+	t.Run("log refuses records after a torn frame it cannot cut off", func(t *testing.T) {
+		dir := t.TempDir()
+
+		r, err := persistence.NewFileRepository(dir)
+		assert.NoError(t, err)
+		assert.NoError(t, r.Create(&domain.Device{ID: "1", Algorithm: "RSA"}))
+
+		persistence.FailNextLogWrite(r, 5, true)
+		_, err = r.Update("1", func(device *domain.Device) error {
+			device.SignatureCounter = 1
+			return nil
+		})
+		assert.Error(t, err)
+
+		_, err = r.Update("1", func(device *domain.Device) error {
+			device.SignatureCounter = 2
+			return nil
+		})
+		assert.Error(t, err)
+
+		got, err := r.GetByID("1")
+		assert.NoError(t, err)
+		assert.Equal(t, 0, got.SignatureCounter)
+	})
+}
+
+func TestFileRepository_Update(t *testing.T) {
+	t.Run("failed update leaves device untouched", func(t *testing.T) {
+		dir := t.TempDir()
+
+		r, err := persistence.NewFileRepository(dir)
+		assert.NoError(t, err)
+
+		device := &domain.Device{ID: "1", Algorithm: "RSA", LastSignature: "MQ"}
+		assert.NoError(t, r.Create(device))
+
+		errUpdate := errors.New("update failed")
+		_, gotErr := r.Update(device.ID, func(device *domain.Device) error {
+			device.SignatureCounter++
+			device.LastSignature = "Updated Signature"
+			return errUpdate
+		})
+		assert.ErrorIs(t, gotErr, errUpdate)
+
+		got, err := r.GetByID(device.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, got.SignatureCounter)
+		assert.Equal(t, "MQ", got.LastSignature)
+
+		reopened, err := persistence.NewFileRepository(dir)
+		assert.NoError(t, err)
+
+		got, err = reopened.GetByID(device.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, got.SignatureCounter)
+	})
+
+	t.Run("update non existing device", func(t *testing.T) {
+		r, err := persistence.NewFileRepository(t.TempDir())
+		assert.NoError(t, err)
+		defer r.Close()
+
+		_, gotErr := r.Update("1", func(device *domain.Device) error {
+			return nil
+		})
+		assert.EqualError(t, gotErr, domain.ErrDeviceNotFound.Error())
+	})
+}
@@ -0,0 +1,138 @@
+package persistence
+
+import (
+	"bytes"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+const transactionLogFileName = "transactions.wal"
+
+// FileTransactionRepository is a durable TransactionRepository backed by a data directory.
+// The signature history is append-only, so its write-ahead log is the store itself
+// and is never compacted.
+type FileTransactionRepository struct {
+	mu           sync.RWMutex
+	transactions map[string][]*domain.Transaction
+	keys         *idempotencyIndex
+	log          *writeAheadLog
+}
+
+// NewFileTransactionRepository opens or creates a FileTransactionRepository in dir
+// and restores the signature history from its log.
+func NewFileTransactionRepository(dir string) (*FileTransactionRepository, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	r := &FileTransactionRepository{
+		mu:           sync.RWMutex{},
+		transactions: make(map[string][]*domain.Transaction),
+		keys:         newIdempotencyIndex(),
+	}
+
+	log, err := openWriteAheadLog(filepath.Join(dir, transactionLogFileName), func(payload []byte) error {
+		var transactions []*domain.Transaction
+		if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&transactions); err != nil {
+			return err
+		}
+
+		return r.apply(transactions)
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.log = log
+
+	return r, nil
+}
+
+// Append writes the transactions to the log as a single record, so either all
+// of them are recorded or none.
+func (r *FileTransactionRepository) Append(transactions ...*domain.Transaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	chains, err := appendToChains(r.transactions, transactions)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(transactions); err != nil {
+		return err
+	}
+
+	if err := r.log.Append(buf.Bytes()); err != nil {
+		return err
+	}
+
+	for deviceID, chain := range chains {
+		r.keys.replace(deviceID, r.transactions[deviceID], chain)
+		r.transactions[deviceID] = chain
+	}
+
+	return nil
+}
+
+func (r *FileTransactionRepository) ListByDevice(deviceID string) ([]*domain.Transaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	chain := r.transactions[deviceID]
+	transactions := make([]*domain.Transaction, len(chain))
+	copy(transactions, chain)
+
+	return transactions, nil
+}
+
+func (r *FileTransactionRepository) GetByCounter(deviceID string, counter int) (*domain.Transaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	chain := r.transactions[deviceID]
+	if counter < 0 || counter >= len(chain) {
+		return nil, domain.ErrTransactionNotFound
+	}
+
+	return chain[counter], nil
+}
+
+func (r *FileTransactionRepository) GetByIdempotencyKey(deviceID string, key string, now time.Time) (*domain.Transaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	transaction, exists := r.keys.get(deviceID, key, now)
+	if !exists {
+		return nil, domain.ErrTransactionNotFound
+	}
+
+	return transaction, nil
+}
+
+// Close releases the log file.
+func (r *FileTransactionRepository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.log.Close()
+}
+
+func (r *FileTransactionRepository) apply(transactions []*domain.Transaction) error {
+	chains, err := appendToChains(r.transactions, transactions)
+	if err != nil {
+		return err
+	}
+
+	for deviceID, chain := range chains {
+		r.keys.replace(deviceID, r.transactions[deviceID], chain)
+		r.transactions[deviceID] = chain
+	}
+
+	return nil
+}
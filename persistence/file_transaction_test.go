@@ -0,0 +1,45 @@
+package persistence_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileTransactionRepository_Restore(t *testing.T) {
+	dir := t.TempDir()
+
+	r, err := persistence.NewFileTransactionRepository(dir)
+	assert.NoError(t, err)
+
+	assert.NoError(t, r.Append(&domain.Transaction{DeviceID: "1", Counter: 0, Data: "first", CreatedAt: time.Now(), IdempotencyKey: "order-1", IdempotencyExpiresAt: time.Now().Add(time.Hour)}))
+	assert.NoError(t, r.Append(
+		&domain.Transaction{DeviceID: "1", Counter: 1, Data: "second", CreatedAt: time.Now()},
+		&domain.Transaction{DeviceID: "2", Counter: 0, Data: "other", CreatedAt: time.Now()},
+	))
+	assert.NoError(t, r.Close())
+
+	reopened, err := persistence.NewFileTransactionRepository(dir)
+	assert.NoError(t, err)
+	defer reopened.Close()
+
+	got, err := reopened.ListByDevice("1")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(got))
+	assert.Equal(t, "second", got[1].Data)
+
+	transaction, err := reopened.GetByCounter("2", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "other", transaction.Data)
+
+	// idempotency keys are restored with the history
+	transaction, err = reopened.GetByIdempotencyKey("1", "order-1", time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, "first", transaction.Data)
+
+	gotErr := reopened.Append(&domain.Transaction{DeviceID: "1", Counter: 5})
+	assert.EqualError(t, gotErr, domain.ErrTransactionGap.Error())
+}
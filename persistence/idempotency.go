@@ -0,0 +1,88 @@
+package persistence
+
+import (
+	"time"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+// minIdempotencySweep is the number of keys a device may hold before its index is first
+// swept for expired keys.
+const minIdempotencySweep = 64
+
+// idempotencyIndex finds the chain entry a device signed for an idempotency key. Keys live
+// on the transactions, so the index is rebuilt from the history on restart. Expired keys
+// are swept whenever the keys of a device have doubled since the last sweep, which keeps
+// the index at about twice the keys live within their expiry.
+type idempotencyIndex struct {
+	keys  map[string]map[string]*domain.Transaction
+	swept map[string]int
+}
+
+func newIdempotencyIndex() *idempotencyIndex {
+	return &idempotencyIndex{
+		keys:  make(map[string]map[string]*domain.Transaction),
+		swept: make(map[string]int),
+	}
+}
+
+// get returns the transaction signed for key by the device, if the key has not expired yet.
+func (i *idempotencyIndex) get(deviceID string, key string, now time.Time) (*domain.Transaction, bool) {
+	transaction, exists := i.keys[deviceID][key]
+	if !exists || !now.Before(transaction.IdempotencyExpiresAt) {
+		return nil, false
+	}
+
+	return transaction, true
+}
+
+// replace moves the index of a device from its previous chain to the updated one: keys of
+// superseded entries are dropped and keys of appended entries added.
+func (i *idempotencyIndex) replace(deviceID string, previous []*domain.Transaction, updated []*domain.Transaction) {
+	shared := 0
+	for shared < len(previous) && shared < len(updated) && previous[shared] == updated[shared] {
+		shared++
+	}
+
+	keys := i.keys[deviceID]
+	for _, transaction := range previous[shared:] {
+		if transaction.IdempotencyKey != "" && keys[transaction.IdempotencyKey] == transaction {
+			delete(keys, transaction.IdempotencyKey)
+		}
+	}
+
+	var now time.Time
+	for _, transaction := range updated[shared:] {
+		if transaction.IdempotencyKey == "" {
+			continue
+		}
+
+		if keys == nil {
+			keys = make(map[string]*domain.Transaction)
+			i.keys[deviceID] = keys
+		}
+		keys[transaction.IdempotencyKey] = transaction
+		now = transaction.CreatedAt
+	}
+
+	threshold := 2 * i.swept[deviceID]
+	if threshold < minIdempotencySweep {
+		threshold = minIdempotencySweep
+	}
+
+	if len(keys) > threshold {
+		i.sweep(deviceID, now)
+	}
+}
+
+// sweep drops the keys of a device that have expired at now.
+func (i *idempotencyIndex) sweep(deviceID string, now time.Time) {
+	keys := i.keys[deviceID]
+	for key, transaction := range keys {
+		if !now.Before(transaction.IdempotencyExpiresAt) {
+			delete(keys, key)
+		}
+	}
+
+	i.swept[deviceID] = len(keys)
+}
@@ -6,15 +6,18 @@ import (
 	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
 )
 
+// InMemoryRepository keeps devices in memory. Updates of a device are serialised by a
+// lock of its own, so only the short lookup and publication of a device go through the
+// repository-wide lock.
 type InMemoryRepository struct {
 	mu      sync.RWMutex
-	devices map[string]*domain.Device
+	devices map[string]*deviceEntry
 }
 
 func NewInMemoryRepository() Repository {
 	return &InMemoryRepository{
 		mu:      sync.RWMutex{},
-		devices: make(map[string]*domain.Device),
+		devices: make(map[string]*deviceEntry),
 	}
 }
 
@@ -26,7 +29,7 @@ func (r *InMemoryRepository) Create(device *domain.Device) error {
 		return domain.ErrDeviceAlreadyExists
 	}
 
-	r.devices[device.ID] = device
+	r.devices[device.ID] = newDeviceEntry(snapshotOf(device))
 	return nil
 }
 
@@ -34,12 +37,12 @@ func (r *InMemoryRepository) GetByID(id string) (*domain.Device, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	device, exists := r.devices[id]
+	entry, exists := r.devices[id]
 	if !exists {
 		return nil, domain.ErrDeviceNotFound
 	}
 
-	return device, nil
+	return snapshotOf(entry.device), nil
 }
 
 func (r *InMemoryRepository) FindAll() ([]*domain.Device, error) {
@@ -47,25 +50,46 @@ func (r *InMemoryRepository) FindAll() ([]*domain.Device, error) {
 	defer r.mu.RUnlock()
 
 	devices := make([]*domain.Device, 0)
-	for _, device := range r.devices {
-		devices = append(devices, device)
+	for _, entry := range r.devices {
+		devices = append(devices, snapshotOf(entry.device))
 	}
 
 	return devices, nil
 }
 
-func (r *InMemoryRepository) Update(deviceID string, updateFn func(*domain.Device) error) (*domain.Device, error) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+func (r *InMemoryRepository) Query(query domain.DeviceQuery) (*domain.DevicePage, error) {
+	devices, err := r.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return queryDevices(devices, query)
+}
 
-	device, exists := r.devices[deviceID]
+// Update applies updateFn to a copy of the device while holding the lock of that device
+// only, and publishes the result if updateFn succeeds. Updates of the same device are
+// strictly sequential; updates of different devices run in parallel.
+func (r *InMemoryRepository) Update(deviceID string, updateFn func(*domain.Device) error) (*domain.Device, error) {
+	r.mu.RLock()
+	entry, exists := r.devices[deviceID]
+	r.mu.RUnlock()
 	if !exists {
 		return nil, domain.ErrDeviceNotFound
 	}
 
-	if err := updateFn(device); err != nil {
+	entry.update.Lock()
+	defer entry.update.Unlock()
+
+	// only holders of the device lock write the device, so it can be copied without the
+	// repository lock
+	updated := snapshotOf(entry.device)
+	if err := updateFn(updated); err != nil {
 		return nil, err
 	}
 
-	return device, nil
+	r.mu.Lock()
+	*entry.device = *updated
+	r.mu.Unlock()
+
+	return updated, nil
 }
@@ -1,6 +1,8 @@
 package persistence_test
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -131,6 +133,40 @@ func TestInMemoryRepository_Create(t *testing.T) {
 			assert.Equal(t, 1, successCount)
 		})
 	})
+
+	t.Run("stored device is a copy", func(t *testing.T) {
+		r := persistence.NewInMemoryRepository()
+
+		device := &domain.Device{ID: "1", Algorithm: "RSA", Label: "Test Device"}
+		assert.NoError(t, r.Create(device))
+
+		// run with -race: updates must not write to the device of the caller,
+		// which the API still reads to answer the create request
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				_, err := r.Update("1", func(device *domain.Device) error {
+					device.SignatureCounter++
+					device.LastSignature = "Updated Signature"
+					return nil
+				})
+				assert.NoError(t, err)
+			}
+		}()
+
+		for i := 0; i < 100; i++ {
+			assert.Equal(t, 0, device.SignatureCounter)
+			assert.Equal(t, "", device.LastSignature)
+		}
+		wg.Wait()
+
+		got, err := r.GetByID("1")
+		assert.NoError(t, err)
+		assert.Equal(t, 100, got.SignatureCounter)
+		assert.Equal(t, 0, device.SignatureCounter)
+	})
 }
 
 func TestInMemoryRepository_GetByID(t *testing.T) {
@@ -251,3 +287,180 @@ func TestInMemoryRepository_Update(t *testing.T) {
 		assert.EqualError(t, gotErr, domain.ErrDeviceNotFound.Error())
 	})
 }
+
+func TestInMemoryRepository_PerDeviceLocking(t *testing.T) {
+	t.Run("slow update does not block other devices", func(t *testing.T) {
+		r := persistence.NewInMemoryRepository()
+		assert.NoError(t, r.Create(&domain.Device{ID: "1", Algorithm: "RSA"}))
+		assert.NoError(t, r.Create(&domain.Device{ID: "2", Algorithm: "RSA"}))
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		done := make(chan error)
+		go func() {
+			_, err := r.Update("1", func(device *domain.Device) error {
+				close(started)
+				<-release
+				device.SignatureCounter++
+				return nil
+			})
+			done <- err
+		}()
+		<-started
+
+		// device 1 is being updated: reads and updates of device 2 go ahead
+		got, err := r.GetByID("1")
+		assert.NoError(t, err)
+		assert.Equal(t, 0, got.SignatureCounter, "unfinished update should not be visible")
+
+		_, err = r.FindAll()
+		assert.NoError(t, err)
+
+		_, err = r.Update("2", func(device *domain.Device) error {
+			device.SignatureCounter++
+			return nil
+		})
+		assert.NoError(t, err)
+
+		close(release)
+		assert.NoError(t, <-done)
+
+		got, err = r.GetByID("1")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, got.SignatureCounter)
+	})
+
+	t.Run("updates of one device stay sequential", func(t *testing.T) {
+		r := persistence.NewInMemoryRepository()
+		assert.NoError(t, r.Create(&domain.Device{ID: "1", Algorithm: "RSA"}))
+
+		const numConcurrent = 50
+		var wg sync.WaitGroup
+		for i := 0; i < numConcurrent; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := r.Update("1", func(device *domain.Device) error {
+					device.SignatureCounter++
+					return nil
+				})
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		got, err := r.GetByID("1")
+		assert.NoError(t, err)
+		assert.Equal(t, numConcurrent, got.SignatureCounter)
+	})
+}
+
+func TestInMemoryRepository_Query(t *testing.T) {
+	r := persistence.NewInMemoryRepository()
+
+	base := time.Date(2025, 10, 26, 7, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		algorithm := "RSA"
+		if i%2 == 1 {
+			algorithm = "ECC"
+		}
+
+		assert.NoError(t, r.Create(&domain.Device{
+			ID:               string(rune('A' + i)),
+			Algorithm:        algorithm,
+			Label:            fmt.Sprintf("Register %d", i),
+			Metadata:         map[string]string{"till": fmt.Sprint(i % 3)},
+			SignatureCounter: 10 - i,
+			CreatedAt:        base.Add(time.Duration(i) * time.Hour),
+		}))
+	}
+
+	ids := func(devices []*domain.Device) []string {
+		got := make([]string, 0, len(devices))
+		for _, device := range devices {
+			got = append(got, device.ID)
+		}
+		return got
+	}
+
+	t.Run("walk all pages", func(t *testing.T) {
+		query := domain.DeviceQuery{SortBy: domain.DeviceSortCreatedAt, Limit: 4}
+
+		got := make([]string, 0)
+		pages := 0
+		for {
+			page, err := r.Query(query)
+			assert.NoError(t, err)
+			got = append(got, ids(page.Devices)...)
+			pages++
+
+			if page.NextCursor == "" {
+				break
+			}
+			query.Cursor = page.NextCursor
+		}
+
+		assert.Equal(t, 3, pages)
+		assert.Equal(t, []string{"A", "B", "C", "D", "E", "F", "G", "H", "I", "J"}, got)
+	})
+
+	t.Run("filter and sort", func(t *testing.T) {
+		after := base.Add(2 * time.Hour)
+		before := base.Add(8 * time.Hour)
+
+		page, err := r.Query(domain.DeviceQuery{
+			Algorithm:     "ECC",
+			CreatedAfter:  &after,
+			CreatedBefore: &before,
+			SortBy:        domain.DeviceSortSignatureCounter,
+			Limit:         10,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"H", "F", "D"}, ids(page.Devices))
+		assert.Empty(t, page.NextCursor)
+
+		page, err = r.Query(domain.DeviceQuery{
+			LabelContains: "register 1",
+			SortBy:        domain.DeviceSortCreatedAt,
+			Descending:    true,
+			Limit:         10,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"B"}, ids(page.Devices))
+
+		page, err = r.Query(domain.DeviceQuery{
+			Metadata: map[string]string{"till": "0"},
+			SortBy:   domain.DeviceSortCreatedAt,
+			Limit:    10,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"A", "D", "G", "J"}, ids(page.Devices))
+	})
+
+	t.Run("descending pages", func(t *testing.T) {
+		page, err := r.Query(domain.DeviceQuery{SortBy: domain.DeviceSortCreatedAt, Descending: true, Limit: 3})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"J", "I", "H"}, ids(page.Devices))
+
+		page, err = r.Query(domain.DeviceQuery{SortBy: domain.DeviceSortCreatedAt, Descending: true, Limit: 3, Cursor: page.NextCursor})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"G", "F", "E"}, ids(page.Devices))
+	})
+
+	t.Run("invalid queries", func(t *testing.T) {
+		_, err := r.Query(domain.DeviceQuery{SortBy: "label", Limit: 10})
+		assert.ErrorIs(t, err, domain.ErrInvalidSortField)
+
+		_, err = r.Query(domain.DeviceQuery{SortBy: domain.DeviceSortCreatedAt, Limit: domain.MaxPageLimit + 1})
+		assert.ErrorIs(t, err, domain.ErrInvalidPageLimit)
+
+		_, err = r.Query(domain.DeviceQuery{SortBy: domain.DeviceSortCreatedAt, Limit: 10, Cursor: "not a cursor"})
+		assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+
+		// a cursor only continues the order it was issued for
+		page, err := r.Query(domain.DeviceQuery{SortBy: domain.DeviceSortCreatedAt, Limit: 1})
+		assert.NoError(t, err)
+		_, err = r.Query(domain.DeviceQuery{SortBy: domain.DeviceSortSignatureCounter, Limit: 1, Cursor: page.NextCursor})
+		assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+	})
+}
@@ -0,0 +1,105 @@
+package persistence
+
+import (
+	"sync"
+	"time"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+type InMemoryTransactionRepository struct {
+	mu           sync.RWMutex
+	transactions map[string][]*domain.Transaction
+	keys         *idempotencyIndex
+}
+
+func NewInMemoryTransactionRepository() TransactionRepository {
+	return &InMemoryTransactionRepository{
+		mu:           sync.RWMutex{},
+		transactions: make(map[string][]*domain.Transaction),
+		keys:         newIdempotencyIndex(),
+	}
+}
+
+// Append adds the transactions to the chains of their devices. Either all
+// transactions are appended or, if one of them does not continue its chain, none.
+func (r *InMemoryTransactionRepository) Append(transactions ...*domain.Transaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	chains, err := appendToChains(r.transactions, transactions)
+	if err != nil {
+		return err
+	}
+
+	for deviceID, chain := range chains {
+		r.keys.replace(deviceID, r.transactions[deviceID], chain)
+		r.transactions[deviceID] = chain
+	}
+
+	return nil
+}
+
+func (r *InMemoryTransactionRepository) ListByDevice(deviceID string) ([]*domain.Transaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	chain := r.transactions[deviceID]
+	transactions := make([]*domain.Transaction, len(chain))
+	copy(transactions, chain)
+
+	return transactions, nil
+}
+
+func (r *InMemoryTransactionRepository) GetByCounter(deviceID string, counter int) (*domain.Transaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	chain := r.transactions[deviceID]
+	if counter < 0 || counter >= len(chain) {
+		return nil, domain.ErrTransactionNotFound
+	}
+
+	return chain[counter], nil
+}
+
+func (r *InMemoryTransactionRepository) GetByIdempotencyKey(deviceID string, key string, now time.Time) (*domain.Transaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	transaction, exists := r.keys.get(deviceID, key, now)
+	if !exists {
+		return nil, domain.ErrTransactionNotFound
+	}
+
+	return transaction, nil
+}
+
+// appendToChains returns the chains of all devices touched by transactions
+// with the transactions appended, without modifying the given chains.
+//
+// A transaction must either continue its chain or reuse the counter of an
+// existing entry. The latter supersedes the entry and everything after it;
+// such a stale tail is only left behind when the device could not be
+// written after its transaction had been recorded.
+func appendToChains(existing map[string][]*domain.Transaction, transactions []*domain.Transaction) (map[string][]*domain.Transaction, error) {
+	chains := make(map[string][]*domain.Transaction)
+	for _, transaction := range transactions {
+		chain, touched := chains[transaction.DeviceID]
+		if !touched {
+			chain = existing[transaction.DeviceID]
+		}
+
+		if transaction.Counter < 0 || transaction.Counter > len(chain) {
+			return nil, domain.ErrTransactionGap
+		}
+
+		if transaction.Counter < len(chain) {
+			chain = append([]*domain.Transaction(nil), chain[:transaction.Counter]...)
+		}
+
+		chains[transaction.DeviceID] = append(chain, transaction)
+	}
+
+	return chains, nil
+}
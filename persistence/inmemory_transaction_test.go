@@ -0,0 +1,156 @@
+package persistence_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInMemoryTransactionRepository_Append(t *testing.T) {
+	t.Run("append consecutive transactions", func(t *testing.T) {
+		r := persistence.NewInMemoryTransactionRepository()
+
+		for counter := 0; counter < 3; counter++ {
+			err := r.Append(&domain.Transaction{
+				DeviceID:  "1",
+				Counter:   counter,
+				Data:      "COFFEE",
+				CreatedAt: time.Now(),
+			})
+			assert.NoError(t, err)
+		}
+
+		got, gotErr := r.ListByDevice("1")
+		assert.NoError(t, gotErr)
+		assert.Equal(t, 3, len(got))
+		for counter, transaction := range got {
+			assert.Equal(t, counter, transaction.Counter)
+		}
+	})
+
+	t.Run("append transaction with a gap", func(t *testing.T) {
+		r := persistence.NewInMemoryTransactionRepository()
+
+		gotErr := r.Append(&domain.Transaction{DeviceID: "1", Counter: 1})
+		assert.EqualError(t, gotErr, domain.ErrTransactionGap.Error())
+	})
+
+	t.Run("append is all or nothing", func(t *testing.T) {
+		r := persistence.NewInMemoryTransactionRepository()
+
+		gotErr := r.Append(
+			&domain.Transaction{DeviceID: "1", Counter: 0},
+			&domain.Transaction{DeviceID: "1", Counter: 2},
+		)
+		assert.EqualError(t, gotErr, domain.ErrTransactionGap.Error())
+
+		got, gotErr := r.ListByDevice("1")
+		assert.NoError(t, gotErr)
+		assert.Equal(t, 0, len(got))
+	})
+
+	t.Run("reused counter supersedes stale tail", func(t *testing.T) {
+		r := persistence.NewInMemoryTransactionRepository()
+
+		assert.NoError(t, r.Append(
+			&domain.Transaction{DeviceID: "1", Counter: 0, Data: "first"},
+			&domain.Transaction{DeviceID: "1", Counter: 1, Data: "stale"},
+		))
+		assert.NoError(t, r.Append(&domain.Transaction{DeviceID: "1", Counter: 1, Data: "second"}))
+
+		got, gotErr := r.ListByDevice("1")
+		assert.NoError(t, gotErr)
+		assert.Equal(t, 2, len(got))
+		assert.Equal(t, "second", got[1].Data)
+	})
+}
+
+func TestInMemoryTransactionRepository_GetByCounter(t *testing.T) {
+	r := persistence.NewInMemoryTransactionRepository()
+	assert.NoError(t, r.Append(&domain.Transaction{DeviceID: "1", Counter: 0, Data: "COFFEE"}))
+
+	t.Run("get existing transaction", func(t *testing.T) {
+		got, gotErr := r.GetByCounter("1", 0)
+		assert.NoError(t, gotErr)
+		assert.Equal(t, "COFFEE", got.Data)
+	})
+
+	t.Run("get non existing transaction", func(t *testing.T) {
+		_, gotErr := r.GetByCounter("1", 1)
+		assert.EqualError(t, gotErr, domain.ErrTransactionNotFound.Error())
+
+		_, gotErr = r.GetByCounter("2", 0)
+		assert.EqualError(t, gotErr, domain.ErrTransactionNotFound.Error())
+	})
+}
+
+func TestInMemoryTransactionRepository_GetByIdempotencyKey(t *testing.T) {
+	now := time.Now()
+	keyed := func(counter int, key string, data string) *domain.Transaction {
+		return &domain.Transaction{
+			DeviceID:             "1",
+			Counter:              counter,
+			Data:                 data,
+			CreatedAt:            now,
+			IdempotencyKey:       key,
+			IdempotencyExpiresAt: now.Add(time.Hour),
+		}
+	}
+
+	t.Run("get keyed transaction", func(t *testing.T) {
+		r := persistence.NewInMemoryTransactionRepository()
+		assert.NoError(t, r.Append(keyed(0, "order-1", "COFFEE"), keyed(1, "", "TEA")))
+
+		got, err := r.GetByIdempotencyKey("1", "order-1", now)
+		assert.NoError(t, err)
+		assert.Equal(t, "COFFEE", got.Data)
+
+		_, err = r.GetByIdempotencyKey("2", "order-1", now)
+		assert.ErrorIs(t, err, domain.ErrTransactionNotFound)
+	})
+
+	t.Run("expired key is not found", func(t *testing.T) {
+		r := persistence.NewInMemoryTransactionRepository()
+		assert.NoError(t, r.Append(keyed(0, "order-1", "COFFEE")))
+
+		_, err := r.GetByIdempotencyKey("1", "order-1", now.Add(time.Hour))
+		assert.ErrorIs(t, err, domain.ErrTransactionNotFound)
+	})
+
+	t.Run("superseded entry loses its key", func(t *testing.T) {
+		r := persistence.NewInMemoryTransactionRepository()
+		assert.NoError(t, r.Append(keyed(0, "", "COFFEE"), keyed(1, "order-1", "STALE")))
+		assert.NoError(t, r.Append(keyed(1, "order-2", "TEA")))
+
+		_, err := r.GetByIdempotencyKey("1", "order-1", now)
+		assert.ErrorIs(t, err, domain.ErrTransactionNotFound)
+
+		got, err := r.GetByIdempotencyKey("1", "order-2", now)
+		assert.NoError(t, err)
+		assert.Equal(t, "TEA", got.Data)
+	})
+
+	t.Run("many keys", func(t *testing.T) {
+		r := persistence.NewInMemoryTransactionRepository()
+
+		// keys expire one after the other while later ones are swept in
+		for counter := 0; counter < 500; counter++ {
+			transaction := keyed(counter, fmt.Sprintf("order-%d", counter), "COFFEE")
+			transaction.CreatedAt = now.Add(time.Duration(counter) * time.Second)
+			transaction.IdempotencyExpiresAt = transaction.CreatedAt.Add(100 * time.Second)
+			assert.NoError(t, r.Append(transaction))
+		}
+
+		last := now.Add(499 * time.Second)
+		_, err := r.GetByIdempotencyKey("1", "order-100", last)
+		assert.ErrorIs(t, err, domain.ErrTransactionNotFound)
+
+		got, err := r.GetByIdempotencyKey("1", "order-450", last)
+		assert.NoError(t, err)
+		assert.Equal(t, 450, got.Counter)
+	})
+}
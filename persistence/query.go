@@ -0,0 +1,164 @@
+package persistence
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+// deviceCursor is the position of a listing: the sort key and ID of the last device
+// returned, and the order it was returned in.
+type deviceCursor struct {
+	SortBy     string `json:"s"`
+	Descending bool   `json:"d,omitempty"`
+	Key        int64  `json:"k"`
+	ID         string `json:"i"`
+}
+
+// queryDevices answers a query over the given devices. Repositories without an index
+// of their own use it on a snapshot of their devices.
+func queryDevices(devices []*domain.Device, query domain.DeviceQuery) (*domain.DevicePage, error) {
+	if err := validateQuery(query); err != nil {
+		return nil, err
+	}
+
+	var after *deviceCursor
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.SortBy != query.SortBy || cursor.Descending != query.Descending {
+			return nil, domain.ErrInvalidCursor
+		}
+		after = cursor
+	}
+
+	matching := make([]*domain.Device, 0)
+	for _, device := range devices {
+		if matchesQuery(device, query) {
+			matching = append(matching, device)
+		}
+	}
+
+	sort.Slice(matching, func(i, j int) bool {
+		a, b := matching[i], matching[j]
+		return lessAt(a, sortKey(a, query.SortBy), b, sortKey(b, query.SortBy), query.Descending)
+	})
+
+	start := 0
+	if after != nil {
+		// the first device that sorts after the cursor position
+		position := &domain.Device{ID: after.ID}
+		start = sort.Search(len(matching), func(i int) bool {
+			return lessAt(position, after.Key, matching[i], sortKey(matching[i], query.SortBy), query.Descending)
+		})
+	}
+
+	end := start + query.Limit
+	if end > len(matching) {
+		end = len(matching)
+	}
+
+	page := &domain.DevicePage{Devices: matching[start:end]}
+	if end < len(matching) {
+		last := matching[end-1]
+		page.NextCursor = encodeCursor(deviceCursor{
+			SortBy:     query.SortBy,
+			Descending: query.Descending,
+			Key:        sortKey(last, query.SortBy),
+			ID:         last.ID,
+		})
+	}
+
+	return page, nil
+}
+
+// lessAt reports whether device a with sort key keyA comes before device b with keyB.
+func lessAt(a *domain.Device, keyA int64, b *domain.Device, keyB int64, descending bool) bool {
+	if keyA != keyB {
+		if descending {
+			return keyA > keyB
+		}
+		return keyA < keyB
+	}
+
+	if descending {
+		return a.ID > b.ID
+	}
+	return a.ID < b.ID
+}
+
+func validateQuery(query domain.DeviceQuery) error {
+	if query.Limit < 1 || query.Limit > domain.MaxPageLimit {
+		return domain.ErrInvalidPageLimit
+	}
+
+	switch query.SortBy {
+	case domain.DeviceSortCreatedAt, domain.DeviceSortSignatureCounter:
+	default:
+		return domain.ErrInvalidSortField
+	}
+
+	if query.CreatedAfter != nil && query.CreatedBefore != nil && query.CreatedAfter.After(*query.CreatedBefore) {
+		return domain.ErrInvalidDateRange
+	}
+
+	return nil
+}
+
+func matchesQuery(device *domain.Device, query domain.DeviceQuery) bool {
+	if query.Algorithm != "" && device.Algorithm != query.Algorithm {
+		return false
+	}
+
+	if query.LabelContains != "" && !strings.Contains(strings.ToLower(device.Label), strings.ToLower(query.LabelContains)) {
+		return false
+	}
+
+	if query.CreatedAfter != nil && device.CreatedAt.Before(*query.CreatedAfter) {
+		return false
+	}
+
+	if query.CreatedBefore != nil && !device.CreatedAt.Before(*query.CreatedBefore) {
+		return false
+	}
+
+	for key, value := range query.Metadata {
+		if stored, exists := device.Metadata[key]; !exists || stored != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sortKey(device *domain.Device, sortBy string) int64 {
+	if sortBy == domain.DeviceSortSignatureCounter {
+		return int64(device.SignatureCounter)
+	}
+
+	return device.CreatedAt.UnixNano()
+}
+
+func encodeCursor(cursor deviceCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(encoded string) (*deviceCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	var cursor deviceCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
@@ -1,10 +1,27 @@
 package persistence
 
-import "github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+import (
+	"time"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
 
 type Repository interface {
 	Create(device *domain.Device) error
 	GetByID(id string) (*domain.Device, error)
 	FindAll() ([]*domain.Device, error)
+	// Query returns the page of devices selected by the query. SortBy and Limit have to be set.
+	Query(query domain.DeviceQuery) (*domain.DevicePage, error)
 	Update(deviceID string, updateFn func(*domain.Device) error) (*domain.Device, error)
 }
+
+// TransactionRepository stores the signature history of every device.
+// Transactions of a device are kept in counter order, starting at 0.
+type TransactionRepository interface {
+	Append(transactions ...*domain.Transaction) error
+	ListByDevice(deviceID string) ([]*domain.Transaction, error)
+	GetByCounter(deviceID string, counter int) (*domain.Transaction, error)
+	// GetByIdempotencyKey returns the transaction a device signed for an idempotency key
+	// that has not expired at now.
+	GetByIdempotencyKey(deviceID string, key string, now time.Time) (*domain.Transaction, error)
+}
@@ -0,0 +1,208 @@
+package persistence
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// walHeaderSize is the size of the frame header preceding every record:
+// a 4 byte payload length followed by a 4 byte CRC32 checksum of the payload.
+const walHeaderSize = 8
+
+// errLogFailed is returned by every append once a failed write could not be rolled
+// back, as records appended after the torn frame would be discarded on replay.
+var errLogFailed = errors.New("write-ahead log failed and no longer accepts records")
+
+// logFile is the part of *os.File the log is written through.
+type logFile interface {
+	io.Writer
+	Sync() error
+	Truncate(size int64) error
+	Seek(offset int64, whence int) (int64, error)
+	Close() error
+}
+
+// writeAheadLog is an append-only file of length-prefixed, checksummed records.
+// A record is only considered written once it has been synced to disk, so a
+// crash can at worst leave a torn record at the tail, which is discarded on replay.
+// A failed append is cut off the log again before the next one is written.
+type writeAheadLog struct {
+	file   logFile
+	size   int64
+	failed bool
+}
+
+// openWriteAheadLog replays every intact record of the log at path through fn,
+// truncates a torn tail left behind by a crash and opens the log for appending.
+func openWriteAheadLog(path string, fn func(payload []byte) error) (*writeAheadLog, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	validSize, err := replay(file, fn)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if err := file.Truncate(validSize); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if _, err := file.Seek(validSize, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &writeAheadLog{file: file, size: validSize}, nil
+}
+
+// replay reads records from the start of file until the end of the log or the
+// first incomplete or corrupted record. It returns the size of the intact prefix.
+func replay(file *os.File, fn func(payload []byte) error) (int64, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	var offset int64
+	header := make([]byte, walHeaderSize)
+	for {
+		if _, err := io.ReadFull(file, header); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return offset, nil
+			}
+			return 0, err
+		}
+
+		size := binary.BigEndian.Uint32(header[0:4])
+		checksum := binary.BigEndian.Uint32(header[4:8])
+
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(file, payload); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return offset, nil
+			}
+			return 0, err
+		}
+
+		if crc32.ChecksumIEEE(payload) != checksum {
+			return offset, nil
+		}
+
+		if err := fn(payload); err != nil {
+			return 0, err
+		}
+
+		offset += int64(walHeaderSize) + int64(size)
+	}
+}
+
+// Append writes a single record and syncs it to disk. If the write or the sync fails,
+// the log is truncated back to its last record, so that no torn frame ends up in front
+// of later records.
+func (w *writeAheadLog) Append(payload []byte) error {
+	if w.failed {
+		return errLogFailed
+	}
+
+	frame := make([]byte, walHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(frame[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(frame[4:8], crc32.ChecksumIEEE(payload))
+	copy(frame[walHeaderSize:], payload)
+
+	if _, err := w.file.Write(frame); err != nil {
+		return w.rollback(err)
+	}
+
+	if err := w.file.Sync(); err != nil {
+		return w.rollback(err)
+	}
+
+	w.size += int64(len(frame))
+	return nil
+}
+
+// rollback discards whatever part of a failed append reached the file and returns err.
+// If that fails too, the log refuses further appends.
+func (w *writeAheadLog) rollback(err error) error {
+	if truncErr := w.file.Truncate(w.size); truncErr != nil {
+		w.failed = true
+		return errors.Join(err, truncErr)
+	}
+
+	if _, seekErr := w.file.Seek(w.size, io.SeekStart); seekErr != nil {
+		w.failed = true
+		return errors.Join(err, seekErr)
+	}
+
+	return err
+}
+
+// Reset discards every record of the log, typically after a snapshot has
+// made them redundant.
+func (w *writeAheadLog) Reset() error {
+	if err := w.file.Truncate(0); err != nil {
+		return err
+	}
+	w.size = 0
+
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	return w.file.Sync()
+}
+
+// Close closes the underlying file.
+func (w *writeAheadLog) Close() error {
+	return w.file.Close()
+}
+
+// writeFileAtomic replaces the file at path with data so that readers either
+// observe the previous or the new content, even across crashes.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+// syncDir flushes directory metadata so that a rename survives a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
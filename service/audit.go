@@ -0,0 +1,175 @@
+package service
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+// AuditDevice walks the stored signature history of a device and verifies every link
+// of the chain: counter continuity, the reference to the previous signature (starting
+// at base64(deviceID)) and the signature itself, under the key the device held at that
+// counter. Every key change has to be announced by a rollover entry signed with the
+// retiring key. The report names the first broken link.
+func (s *deviceService) AuditDevice(deviceID string) (*domain.AuditReport, error) {
+	device, err := s.repository.GetByID(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	counter := device.SignatureCounter
+	lastSignature := device.LastSignature
+
+	transactions, err := s.transactions.ListByDevice(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// signatures issued after the device has been read are not part of this audit
+	if len(transactions) > counter {
+		transactions = transactions[:counter]
+	}
+
+	verifiers := make(map[int]crypto.Verifier)
+	verifierFor := func(key domain.DeviceKey) (crypto.Verifier, error) {
+		if verifier, ok := verifiers[key.Version]; ok {
+			return verifier, nil
+		}
+
+		verifier, err := crypto.NewVerifierFromDevice(device.Algorithm, []byte(key.PublicKey), signatureOptions(device))
+		if err != nil {
+			return nil, err
+		}
+		verifiers[key.Version] = verifier
+
+		return verifier, nil
+	}
+
+	report := &domain.AuditReport{
+		DeviceID: deviceID,
+		Valid:    true,
+	}
+
+	previousSignature := genesisSignature(deviceID)
+	for i, transaction := range transactions {
+		key := device.KeyForCounter(i)
+		verifier, err := verifierFor(key)
+		if err != nil {
+			return nil, err
+		}
+
+		brokenLink := auditTransaction(verifier, device, i, transaction, previousSignature)
+		if brokenLink == nil {
+			brokenLink = auditKeyChange(key, device.KeyForCounter(i+1), transaction)
+		}
+		if brokenLink != nil {
+			report.Valid = false
+			report.BrokenLink = brokenLink
+			return report, nil
+		}
+
+		report.TransactionsChecked++
+		previousSignature = transaction.Signature
+	}
+
+	if len(transactions) < counter {
+		report.Valid = false
+		report.BrokenLink = &domain.ChainBreak{
+			Counter: len(transactions),
+			Reason:  fmt.Sprintf("history ends before the device counter %d", counter),
+		}
+		return report, nil
+	}
+
+	if previousSignature != lastSignature {
+		report.Valid = false
+		report.BrokenLink = &domain.ChainBreak{
+			Counter: counter,
+			Reason:  "last signature of the device does not match the history",
+		}
+	}
+
+	return report, nil
+}
+
+// auditTransaction checks a single link of the chain and returns nil if it is intact.
+func auditTransaction(verifier crypto.Verifier, device *domain.Device, expectedCounter int, transaction *domain.Transaction, previousSignature string) *domain.ChainBreak {
+	if transaction.Counter != expectedCounter {
+		return &domain.ChainBreak{
+			Counter: expectedCounter,
+			Reason:  fmt.Sprintf("expected counter %d, found %d", expectedCounter, transaction.Counter),
+		}
+	}
+
+	if transaction.SignedData != expectedSignedData(device, transaction, previousSignature) {
+		return &domain.ChainBreak{
+			Counter: expectedCounter,
+			Reason:  "signed data does not link to the previous signature",
+		}
+	}
+
+	signature, err := base64.RawStdEncoding.DecodeString(transaction.Signature)
+	if err != nil {
+		return &domain.ChainBreak{
+			Counter: expectedCounter,
+			Reason:  domain.ErrInvalidSignatureEncoding.Error(),
+		}
+	}
+
+	if err := verifier.Verify([]byte(transaction.SignedData), signature); err != nil {
+		return &domain.ChainBreak{
+			Counter: expectedCounter,
+			Reason:  err.Error(),
+		}
+	}
+
+	return nil
+}
+
+// auditKeyChange checks that the key changes after a transaction if and only if the
+// transaction is a rollover entry announcing the next key, and returns nil if it does.
+func auditKeyChange(key domain.DeviceKey, next domain.DeviceKey, transaction *domain.Transaction) *domain.ChainBreak {
+	version, fingerprint, isRollover := parseRolloverData(transaction.Data)
+
+	if !isRollover {
+		if next.Version != key.Version {
+			return &domain.ChainBreak{
+				Counter: transaction.Counter,
+				Reason:  fmt.Sprintf("key version %d is not announced by a rollover entry", next.Version),
+			}
+		}
+		return nil
+	}
+
+	if next.Version == key.Version || next.Version != version {
+		return &domain.ChainBreak{
+			Counter: transaction.Counter,
+			Reason:  fmt.Sprintf("rollover to key version %d does not match the key history", version),
+		}
+	}
+
+	nextFingerprint, err := crypto.PublicKeyFingerprint([]byte(next.PublicKey))
+	if err != nil || nextFingerprint != fingerprint {
+		return &domain.ChainBreak{
+			Counter: transaction.Counter,
+			Reason:  fmt.Sprintf("rollover fingerprint does not match key version %d", version),
+		}
+	}
+
+	return nil
+}
+
+// expectedSignedData encodes the signed data the transaction has to carry to link to the
+// previous signature. In the V1 format it also has to name the device and the time the
+// transaction was recorded at.
+func expectedSignedData(device *domain.Device, transaction *domain.Transaction, previousSignature string) string {
+	return domain.SignedEntry{
+		Format:            device.EffectiveSignedDataFormat(),
+		Counter:           transaction.Counter,
+		DeviceID:          device.ID,
+		Timestamp:         &transaction.CreatedAt,
+		Data:              transaction.Data,
+		PreviousSignature: previousSignature,
+	}.Encode()
+}
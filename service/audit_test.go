@@ -0,0 +1,106 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_deviceService_AuditDevice(t *testing.T) {
+	// setup creates a device and signs three transactions with it
+	setup := func(t *testing.T) (service.DeviceService, persistence.TransactionRepository, string) {
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions)
+
+		// create device
+		id := uuid.New().String()
+		device := &domain.Device{
+			ID:        id,
+			Algorithm: "ECC",
+			Label:     "device-1",
+		}
+
+		err := deviceService.CreateDevice(device)
+		assert.NoError(t, err, "should not fail to create device")
+
+		for _, data := range []string{"COFFEE", "TEA", "CAKE"} {
+			_, err := deviceService.SignTransaction(id, data, "")
+			assert.NoError(t, err, "should not fail to sign transaction")
+		}
+
+		return deviceService, transactions, id
+	}
+
+	t.Run("audit intact chain", func(t *testing.T) {
+		deviceService, _, id := setup(t)
+
+		report, err := deviceService.AuditDevice(id)
+		assert.NoError(t, err, "should not fail to audit device")
+		assert.True(t, report.Valid)
+		assert.Equal(t, 3, report.TransactionsChecked)
+		assert.Nil(t, report.BrokenLink)
+	})
+
+	t.Run("audit device without transactions", func(t *testing.T) {
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions)
+
+		id := uuid.New().String()
+		err := deviceService.CreateDevice(&domain.Device{ID: id, Algorithm: "RSA"})
+		assert.NoError(t, err, "should not fail to create device")
+
+		report, err := deviceService.AuditDevice(id)
+		assert.NoError(t, err, "should not fail to audit device")
+		assert.True(t, report.Valid)
+		assert.Equal(t, 0, report.TransactionsChecked)
+	})
+
+	t.Run("audit chain with tampered data", func(t *testing.T) {
+		deviceService, transactions, id := setup(t)
+
+		tampered, err := transactions.GetByCounter(id, 1)
+		assert.NoError(t, err)
+		tampered.Data = "WHISKY"
+
+		report, err := deviceService.AuditDevice(id)
+		assert.NoError(t, err, "should not fail to audit device")
+		assert.False(t, report.Valid)
+		assert.Equal(t, 1, report.TransactionsChecked)
+		assert.Equal(t, 1, report.BrokenLink.Counter)
+	})
+
+	t.Run("audit chain with forged signature", func(t *testing.T) {
+		deviceService, transactions, id := setup(t)
+
+		forged, err := transactions.GetByCounter(id, 2)
+		assert.NoError(t, err)
+		first, err := transactions.GetByCounter(id, 0)
+		assert.NoError(t, err)
+		forged.Signature = first.Signature
+
+		report, err := deviceService.AuditDevice(id)
+		assert.NoError(t, err, "should not fail to audit device")
+		assert.False(t, report.Valid)
+		assert.Equal(t, 2, report.BrokenLink.Counter)
+		assert.Equal(t, domain.ErrInvalidSignature.Error(), report.BrokenLink.Reason)
+	})
+
+	t.Run("audit unknown device", func(t *testing.T) {
+		deviceService, _, _ := setup(t)
+
+		_, err := deviceService.AuditDevice(uuid.New().String())
+		assert.ErrorIs(t, err, domain.ErrDeviceNotFound)
+	})
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"time"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+// MaxBatchSize is the largest number of items SignTransactions signs in one call.
+const MaxBatchSize = 1000
+
+// SignTransactions signs the items in order as a contiguous segment of the device chain.
+// The whole batch is signed under a single device update: either every item is signed
+// and recorded, or, if one of them fails, none is and the device is left untouched.
+func (s *deviceService) SignTransactions(deviceID string, data []string) ([]*domain.SignatureResult, error) {
+	if len(data) == 0 {
+		return nil, domain.ErrEmptyBatch
+	}
+
+	if len(data) > MaxBatchSize {
+		return nil, domain.ErrBatchTooLarge
+	}
+
+	for _, item := range data {
+		if item == "" {
+			return nil, domain.ErrEmptyData
+		}
+
+		if isReservedData(item) {
+			return nil, domain.ErrReservedData
+		}
+	}
+
+	var results []*domain.SignatureResult
+
+	_, err := s.repository.Update(deviceID, func(device *domain.Device) error {
+		if device.CurrentStatus() != domain.DeviceStatusActive {
+			return domain.ErrDeviceNotActive
+		}
+
+		signer, err := s.signer(device)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		counter := device.SignatureCounter
+		lastSignature := device.LastSignature
+
+		transactions := make([]*domain.Transaction, 0, len(data))
+		for _, item := range data {
+			transaction, err := signEntry(signer, device, counter, item, lastSignature, now)
+			if err != nil {
+				return err
+			}
+
+			transactions = append(transactions, transaction)
+			counter++
+			lastSignature = transaction.Signature
+		}
+
+		// the segment is recorded as a whole before the device advances past it
+		if err := s.transactions.Append(transactions...); err != nil {
+			return err
+		}
+
+		results = make([]*domain.SignatureResult, 0, len(transactions))
+		for _, transaction := range transactions {
+			results = append(results, signatureResult(transaction))
+		}
+
+		device.SignatureCounter = counter
+		device.LastSignature = lastSignature
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,115 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingTransactionRepository refuses to record any transaction.
+type failingTransactionRepository struct {
+	persistence.TransactionRepository
+}
+
+func (failingTransactionRepository) Append(...*domain.Transaction) error {
+	return errors.New("disk full")
+}
+
+func Test_deviceService_SignTransactions(t *testing.T) {
+	t.Run("batch forms a contiguous chain segment", func(t *testing.T) {
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions)
+
+		id := uuid.New().String()
+		device := &domain.Device{ID: id, Algorithm: "ECC"}
+		err := deviceService.CreateDevice(device)
+		assert.NoError(t, err, "should not fail to create device")
+
+		_, err = deviceService.SignTransaction(id, "COFFEE", "")
+		assert.NoError(t, err, "should not fail to sign transaction")
+
+		results, err := deviceService.SignTransactions(id, []string{"TEA", "CAKE", "WATER"})
+		assert.NoError(t, err, "should not fail to sign batch")
+		assert.Equal(t, 3, len(results))
+		for i, result := range results {
+			assert.Equal(t, i+1, result.Counter)
+
+			verification, err := deviceService.VerifySignature(id, result.SignedData, result.Signature)
+			assert.NoError(t, err)
+			assert.True(t, verification.Valid)
+		}
+		device, err = deviceService.GetDevice(id)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, device.SignatureCounter)
+
+		_, err = deviceService.SignTransaction(id, "JUICE", "")
+		assert.NoError(t, err, "should continue the chain after the batch")
+
+		report, err := deviceService.AuditDevice(id)
+		assert.NoError(t, err)
+		assert.True(t, report.Valid)
+		assert.Equal(t, 5, report.TransactionsChecked)
+	})
+
+	t.Run("invalid batches", func(t *testing.T) {
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions)
+
+		id := uuid.New().String()
+		device := &domain.Device{ID: id, Algorithm: "ECC"}
+		err := deviceService.CreateDevice(device)
+		assert.NoError(t, err, "should not fail to create device")
+
+		_, err = deviceService.SignTransactions(id, nil)
+		assert.ErrorIs(t, err, domain.ErrEmptyBatch)
+
+		_, err = deviceService.SignTransactions(id, make([]string, service.MaxBatchSize+1))
+		assert.ErrorIs(t, err, domain.ErrBatchTooLarge)
+
+		_, err = deviceService.SignTransactions(id, []string{"TEA", "", "CAKE"})
+		assert.ErrorIs(t, err, domain.ErrEmptyData)
+
+		_, err = deviceService.SignTransactions(uuid.New().String(), []string{"TEA"})
+		assert.ErrorIs(t, err, domain.ErrDeviceNotFound)
+
+		assert.Equal(t, 0, device.SignatureCounter, "rejected batches should not advance the counter")
+	})
+
+	t.Run("failed batch leaves no trace", func(t *testing.T) {
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := failingTransactionRepository{persistence.NewInMemoryTransactionRepository()}
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions)
+
+		id := uuid.New().String()
+		device := &domain.Device{ID: id, Algorithm: "ECC"}
+		err := deviceService.CreateDevice(device)
+		assert.NoError(t, err, "should not fail to create device")
+		lastSignature := device.LastSignature
+
+		_, err = deviceService.SignTransactions(id, []string{"TEA", "CAKE"})
+		assert.Error(t, err)
+
+		assert.Equal(t, 0, device.SignatureCounter)
+		assert.Equal(t, lastSignature, device.LastSignature)
+
+		history, err := transactions.ListByDevice(id)
+		assert.NoError(t, err)
+		assert.Empty(t, history)
+	})
+}
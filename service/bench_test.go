@@ -0,0 +1,95 @@
+package service_test
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/service"
+	"github.com/google/uuid"
+)
+
+// BenchmarkDeviceService_SignTransaction signs from parallel goroutines, each signing with
+// a device of its own or all sharing a single device. Signatures of different devices are
+// computed in parallel; those of one device are sequential by design.
+func BenchmarkDeviceService_SignTransaction(b *testing.B) {
+	for _, devices := range []int{1, 64} {
+		b.Run(fmt.Sprintf("devices=%d", devices), func(b *testing.B) {
+			// spawn repository
+			repository := persistence.NewInMemoryRepository()
+			transactions := persistence.NewInMemoryTransactionRepository()
+
+			// spawn device service
+			deviceService := service.NewDeviceService(repository, transactions)
+
+			ids := make([]string, devices)
+			for i := range ids {
+				ids[i] = uuid.New().String()
+				if err := deviceService.CreateDevice(&domain.Device{ID: ids[i], Algorithm: "ECC", Curve: "P-256"}); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			var next atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				id := ids[next.Add(1)%int64(devices)]
+				for pb.Next() {
+					if _, err := deviceService.SignTransaction(id, "COFFEE", ""); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkDeviceService_SignerCache compares the per-signature latency of an RSA device
+// whose key is kept in a FileKeyStore, with the parsed signer cached and without.
+func BenchmarkDeviceService_SignerCache(b *testing.B) {
+	for _, tc := range []struct {
+		name string
+		opts []service.Option
+	}{
+		{name: "cached"},
+		{name: "uncached", opts: []service.Option{service.WithoutSignerCache()}},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			masterKey, err := crypto.GenerateMasterKey()
+			if err != nil {
+				b.Fatal(err)
+			}
+			keyEncrypter, err := crypto.NewKeyEncrypter(masterKey)
+			if err != nil {
+				b.Fatal(err)
+			}
+			keyStore, err := crypto.NewFileKeyStore(b.TempDir(), keyEncrypter)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			// spawn repository
+			repository := persistence.NewInMemoryRepository()
+			transactions := persistence.NewInMemoryTransactionRepository()
+
+			// spawn device service
+			deviceService := service.NewDeviceService(repository, transactions,
+				append(tc.opts, service.WithKeyStore(keyStore))...)
+
+			id := uuid.New().String()
+			if err := deviceService.CreateDevice(&domain.Device{ID: id, Algorithm: "RSA"}); err != nil {
+				b.Fatal(err)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := deviceService.SignTransaction(id, "COFFEE", ""); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -2,81 +2,263 @@ package service
 
 import (
 	"encoding/base64"
-	"fmt"
+	"io"
+	"strings"
+	"time"
 
 	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
 	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
 	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
+	"github.com/google/uuid"
 )
 
 type DeviceService interface {
 	CreateDevice(device *domain.Device) error
 	GetDevice(deviceID string) (*domain.Device, error)
 	FindAll() ([]*domain.Device, error)
-	SignTransaction(deviceID string, data string) (*domain.SignatureResult, error)
+	ListDevices(query domain.DeviceQuery) (*domain.DevicePage, error)
+	SignTransaction(deviceID string, data string, idempotencyKey string) (*domain.SignatureResult, error)
+	SignTransactions(deviceID string, data []string) ([]*domain.SignatureResult, error)
+	SignDigest(deviceID string, hashAlgorithm string, digest string, idempotencyKey string) (*domain.SignatureResult, error)
+	SignStream(deviceID string, hashAlgorithm string, content io.Reader, idempotencyKey string) (*domain.SignatureResult, error)
+	VerifySignature(deviceID string, signedData string, signature string) (*domain.VerificationResult, error)
+	AuditDevice(deviceID string) (*domain.AuditReport, error)
+	ListTransactions(deviceID string) ([]*domain.Transaction, error)
+	GetTransaction(deviceID string, counter int) (*domain.Transaction, error)
+	ChangeDeviceStatus(deviceID string, status string, reason string) (*domain.Device, error)
+	RotateKey(deviceID string) (*domain.Device, error)
+	UpdateDevice(deviceID string, update domain.DeviceUpdate) (*domain.Device, error)
 }
 
 type deviceService struct {
-	repository persistence.Repository
+	repository     persistence.Repository
+	transactions   persistence.TransactionRepository
+	keyEncrypter   *crypto.KeyEncrypter
+	keyStore       crypto.KeyStore
+	idempotencyTTL time.Duration
+	signers        *signerCache
 }
 
-func NewDeviceService(repository persistence.Repository) DeviceService {
-	return &deviceService{repository: repository}
+func NewDeviceService(repository persistence.Repository, transactions persistence.TransactionRepository, opts ...Option) DeviceService {
+	s := &deviceService{
+		repository:   repository,
+		transactions: transactions,
+		signers:      newSignerCache(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.keyEncrypter == nil {
+		s.keyEncrypter = crypto.NewEphemeralKeyEncrypter()
+	}
+
+	if s.keyStore == nil {
+		s.keyStore = crypto.NewSoftwareKeyStore()
+	}
+
+	if s.idempotencyTTL <= 0 {
+		s.idempotencyTTL = DefaultIdempotencyTTL
+	}
+
+	return s
 }
 
+// CreateDevice generates the key pair of a new device and stores it. A device without an
+// ID is assigned a time-ordered UUID (version 7).
 func (s *deviceService) CreateDevice(device *domain.Device) error {
-	lastSignature := base64.RawStdEncoding.EncodeToString([]byte(device.ID))
-	gen, err := crypto.NewGenerator(device.Algorithm)
+	if device.ID == "" {
+		id, err := uuid.NewV7()
+		if err != nil {
+			return err
+		}
+		device.ID = id.String()
+	}
+
+	if err := validateLabel(device.Label); err != nil {
+		return err
+	}
+	if err := validateMetadata(device.Metadata); err != nil {
+		return err
+	}
+
+	lastSignature := genesisSignature(device.ID)
+	params, err := crypto.ResolveKeyParameters(device.Algorithm, crypto.KeyParameters{
+		KeySize: device.KeySize,
+		Curve:   device.Curve,
+	})
+	if err != nil {
+		return err
+	}
+
+	device.KeySize = params.KeySize
+	device.Curve = params.Curve
+
+	opts, err := crypto.ResolveSignatureOptions(device.Algorithm, params, signatureOptions(device))
 	if err != nil {
 		return err
 	}
+	device.SignatureScheme = opts.Scheme
+	device.PSSSaltLength = opts.SaltLength
+	device.HashAlgorithm = opts.Hash
+
+	// LEGACY is only kept for the chains of devices that already sign with it
+	switch device.SignedDataFormat {
+	case "":
+		device.SignedDataFormat = domain.SignedDataFormatV1
+	case domain.SignedDataFormatLegacy, domain.SignedDataFormatV1:
+	default:
+		return domain.ErrInvalidSignedDataFormat
+	}
 
-	keyPair, err := gen.Generate()
+	key, err := s.keyStore.Generate(device.Algorithm, params)
 	if err != nil {
 		return err
 	}
 
-	device.PrivateKey = string(keyPair.GetPrivateKeyPEM())
-	device.PublicKey = string(keyPair.GetPublicKeyPEM())
+	device.KeyHandle = key.Handle
+	device.PrivateKey = ""
+	device.PublicKey = string(key.PublicKeyPEM)
+	device.KeyVersion = 1
+	device.KeyHistory = []domain.DeviceKey{{
+		Version:          1,
+		PublicKey:        device.PublicKey,
+		ValidFromCounter: 0,
+		CreatedAt:        time.Now(),
+	}}
+
+	device.Status = domain.DeviceStatusActive
+	device.Version = 1
 
 	// pre-sign the device
 	device.SignatureCounter = 0
 	device.LastSignature = lastSignature
 
-	return s.repository.Create(device)
+	if err := s.repository.Create(device); err != nil {
+		// do not leave an orphaned key behind in the key store
+		_ = s.keyStore.Delete(key.Handle)
+		return err
+	}
+
+	return nil
+}
+
+// SignTransaction signs data as the next entry of the device chain. A request carrying an
+// idempotencyKey that the device has already signed under is not signed again: it is
+// answered with the original entry for as long as the key has not expired.
+func (s *deviceService) SignTransaction(deviceID string, data string, idempotencyKey string) (*domain.SignatureResult, error) {
+	if isReservedData(data) {
+		return nil, domain.ErrReservedData
+	}
+
+	return s.sign(deviceID, data, idempotencyKey)
 }
 
-func (s *deviceService) SignTransaction(deviceID string, data string) (*domain.SignatureResult, error) {
+// sign appends data to the device chain. Callers have made sure data is not reserved.
+func (s *deviceService) sign(deviceID string, data string, idempotencyKey string) (*domain.SignatureResult, error) {
+	if err := validateIdempotencyKey(idempotencyKey); err != nil {
+		return nil, err
+	}
+
 	var result *domain.SignatureResult
 
 	_, err := s.repository.Update(deviceID, func(device *domain.Device) error {
-		signer, err := crypto.NewSignerFromDevice(device.Algorithm, []byte(device.PrivateKey))
+		now := time.Now()
+		if idempotencyKey != "" {
+			replayed, err := s.replay(device, idempotencyKey, data, now)
+			if err != nil {
+				return err
+			}
+			if replayed != nil {
+				result = replayed
+				return errIdempotentReplay
+			}
+		}
+
+		if device.CurrentStatus() != domain.DeviceStatusActive {
+			return domain.ErrDeviceNotActive
+		}
+
+		signer, err := s.signer(device)
 		if err != nil {
 			return err
 		}
-		securedData := fmt.Sprintf("%d_%s_%s", device.SignatureCounter, data, device.LastSignature)
 
-		signBytes, err := signer.Sign([]byte(securedData))
+		transaction, err := signEntry(signer, device, device.SignatureCounter, data, device.LastSignature, now)
 		if err != nil {
 			return err
 		}
-		signatureBase64 := base64.RawStdEncoding.EncodeToString(signBytes)
 
-		device.SignatureCounter++
-		device.LastSignature = signatureBase64
+		// the key is kept with the history rather than the device, so a keyed
+		// signature does not grow the device record
+		if idempotencyKey != "" {
+			transaction.IdempotencyKey = idempotencyKey
+			transaction.IdempotencyExpiresAt = now.Add(s.idempotencyTTL)
+		}
 
-		result = &domain.SignatureResult{
-			Signature:  signatureBase64,
-			SignedData: securedData,
+		// record the signature before advancing the device, so the history
+		// never misses an entry of the chain
+		if err := s.transactions.Append(transaction); err != nil {
+			return err
 		}
 
+		result = signatureResult(transaction)
+
+		device.SignatureCounter++
+		device.LastSignature = transaction.Signature
+
 		return nil
 	})
 
+	if err != nil && err != errIdempotentReplay {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// VerifySignature checks whether signature is a valid signature of the device over signedData.
+// The signature is expected in the base64 encoding returned by SignTransaction. It is checked
+// against the key the device held at the counter of signedData, so signatures made before a
+// key rotation stay verifiable. A valid result carries the decomposed signed data.
+func (s *deviceService) VerifySignature(deviceID string, signedData string, signature string) (*domain.VerificationResult, error) {
+	device, err := s.repository.GetByID(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	signatureBytes, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(signature, "="))
+	if err != nil {
+		return nil, domain.ErrInvalidSignatureEncoding
+	}
+
+	publicKey := device.PublicKey
+	entry, parseErr := domain.ParseSignedData(signedData)
+	if parseErr == nil {
+		publicKey = device.KeyForCounter(entry.Counter).PublicKey
+	}
+
+	verifier, err := crypto.NewVerifierFromDevice(device.Algorithm, []byte(publicKey), signatureOptions(device))
 	if err != nil {
 		return nil, err
 	}
 
+	err = verifier.Verify([]byte(signedData), signatureBytes)
+	if err != nil && err != domain.ErrInvalidSignature {
+		return nil, err
+	}
+
+	result := &domain.VerificationResult{
+		DeviceID: device.ID,
+		Valid:    err == nil,
+	}
+
+	// the content of the signed data is only reported once it is known to be authentic
+	if result.Valid && parseErr == nil {
+		result.Entry = entry
+	}
+
 	return result, nil
 }
 
@@ -87,3 +269,156 @@ func (s *deviceService) GetDevice(deviceID string) (*domain.Device, error) {
 func (s *deviceService) FindAll() ([]*domain.Device, error) {
 	return s.repository.FindAll()
 }
+
+// ListDevices returns a page of the devices selected by the query. Devices are sorted by
+// creation time and pages hold DefaultPageLimit devices unless the query says otherwise.
+func (s *deviceService) ListDevices(query domain.DeviceQuery) (*domain.DevicePage, error) {
+	if query.SortBy == "" {
+		query.SortBy = domain.DeviceSortCreatedAt
+	}
+
+	if query.Limit == 0 {
+		query.Limit = domain.DefaultPageLimit
+	}
+
+	return s.repository.Query(query)
+}
+
+// ListTransactions returns the signature history of a device up to its signature counter.
+// Entries beyond it were recorded for a signature the device never completed and are
+// superseded by the next one, so they are not history.
+func (s *deviceService) ListTransactions(deviceID string) ([]*domain.Transaction, error) {
+	device, err := s.repository.GetByID(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := s.transactions.ListByDevice(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(transactions) > device.SignatureCounter {
+		transactions = transactions[:device.SignatureCounter]
+	}
+
+	return transactions, nil
+}
+
+// GetTransaction returns the entry of the signature history of a device at counter. As in
+// ListTransactions, entries beyond the signature counter of the device are not found.
+func (s *deviceService) GetTransaction(deviceID string, counter int) (*domain.Transaction, error) {
+	device, err := s.repository.GetByID(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if counter >= device.SignatureCounter {
+		return nil, domain.ErrTransactionNotFound
+	}
+
+	return s.transactions.GetByCounter(deviceID, counter)
+}
+
+// signer returns the Signer for the key of the device, from the cache if the device has
+// signed with this key before.
+func (s *deviceService) signer(device *domain.Device) (crypto.Signer, error) {
+	if s.signers != nil {
+		if signer := s.signers.get(device); signer != nil {
+			return signer, nil
+		}
+	}
+
+	signer, err := s.loadSigner(device)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.signers != nil {
+		s.signers.put(device, signer)
+	}
+
+	return signer, nil
+}
+
+// loadSigner builds the Signer for the key of the device. Devices created before key custody
+// moved to the key store still carry their private key, which is unwrapped here.
+func (s *deviceService) loadSigner(device *domain.Device) (crypto.Signer, error) {
+	if device.KeyHandle != "" {
+		return s.keyStore.Signer(device.KeyHandle, signatureOptions(device))
+	}
+
+	privateKeyPEM := []byte(device.PrivateKey)
+	if crypto.IsWrappedKey(device.PrivateKey) {
+		var err error
+		privateKeyPEM, err = s.keyEncrypter.Unwrap(device.PrivateKey, []byte(device.ID))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return crypto.NewSignerFromDevice(device.Algorithm, privateKeyPEM, signatureOptions(device))
+}
+
+// signatureOptions collects the signature settings stored on the device.
+func signatureOptions(device *domain.Device) crypto.SignatureOptions {
+	return crypto.SignatureOptions{
+		Scheme:     device.SignatureScheme,
+		SaltLength: device.PSSSaltLength,
+		Hash:       device.HashAlgorithm,
+	}
+}
+
+// signEntry signs data as the chain entry of the device at counter that follows lastSignature,
+// encoded in the signed data format of the device.
+func signEntry(signer crypto.Signer, device *domain.Device, counter int, data string, lastSignature string, now time.Time) (*domain.Transaction, error) {
+	securedData := domain.SignedEntry{
+		Format:            device.EffectiveSignedDataFormat(),
+		Counter:           counter,
+		DeviceID:          device.ID,
+		Timestamp:         &now,
+		Data:              data,
+		PreviousSignature: lastSignature,
+	}.Encode()
+
+	signature, err := signer.Sign([]byte(securedData))
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.Transaction{
+		DeviceID:          device.ID,
+		Counter:           counter,
+		Data:              data,
+		SignedData:        securedData,
+		Signature:         base64.RawStdEncoding.EncodeToString(signature),
+		Algorithm:         device.Algorithm,
+		SignatureScheme:   device.SignatureScheme,
+		HashAlgorithm:     crypto.SignatureHash(device.Algorithm, signatureOptions(device)),
+		KeyVersion:        device.KeyForCounter(counter).Version,
+		PreviousSignature: lastSignature,
+		CreatedAt:         now,
+	}, nil
+}
+
+// signatureResult is the receipt of a signed chain entry. Its timestamp carries no monotonic
+// clock reading, which would not survive storage, so a replayed receipt equals the original.
+func signatureResult(transaction *domain.Transaction) *domain.SignatureResult {
+	return &domain.SignatureResult{
+		DeviceID:          transaction.DeviceID,
+		Counter:           transaction.Counter,
+		Algorithm:         transaction.Algorithm,
+		SignatureScheme:   transaction.SignatureScheme,
+		HashAlgorithm:     transaction.HashAlgorithm,
+		KeyVersion:        transaction.KeyVersion,
+		Timestamp:         transaction.CreatedAt.Round(0),
+		PreviousSignature: transaction.PreviousSignature,
+		Signature:         transaction.Signature,
+		SignedData:        transaction.SignedData,
+	}
+}
+
+// genesisSignature is the value a new device chain starts from in place of a previous signature.
+func genesisSignature(deviceID string) string {
+	return base64.RawStdEncoding.EncodeToString([]byte(deviceID))
+}
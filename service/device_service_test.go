@@ -17,9 +17,10 @@ func Test_deviceService_CreateDevice(t *testing.T) {
 	t.Run("create device", func(t *testing.T) {
 		// spawn repository
 		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
 
 		// spawn device service
-		deviceService := service.NewDeviceService(repository)
+		deviceService := service.NewDeviceService(repository, transactions)
 
 		// create device
 		id := uuid.New().String()
@@ -42,12 +43,44 @@ func Test_deviceService_CreateDevice(t *testing.T) {
 		assert.Equal(t, id, string(lastSignature), "device last signature should match device id")
 	})
 
+	t.Run("create device without ID", func(t *testing.T) {
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions)
+
+		first := &domain.Device{Algorithm: "ECC"}
+		err := deviceService.CreateDevice(first)
+		assert.NoError(t, err, "should not fail to create device")
+
+		second := &domain.Device{Algorithm: "ECC"}
+		err = deviceService.CreateDevice(second)
+		assert.NoError(t, err, "should not fail to create device")
+
+		id, err := uuid.Parse(first.ID)
+		assert.NoError(t, err, "assigned ID should be a UUID")
+		assert.Equal(t, uuid.Version(7), id.Version())
+		assert.NotEqual(t, first.ID, second.ID)
+
+		// the chain of the device starts from its assigned ID
+		lastSignature, err := base64.RawStdEncoding.DecodeString(first.LastSignature)
+		assert.NoError(t, err)
+		assert.Equal(t, first.ID, string(lastSignature))
+
+		stored, err := deviceService.GetDevice(first.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, first.ID, stored.ID)
+	})
+
 	t.Run("create device with invalid algorithm", func(t *testing.T) {
 		// spawn repository
 		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
 
 		// spawn device service
-		deviceService := service.NewDeviceService(repository)
+		deviceService := service.NewDeviceService(repository, transactions)
 
 		// create device
 		id := uuid.New().String()
@@ -66,13 +99,190 @@ func Test_deviceService_CreateDevice(t *testing.T) {
 	})
 }
 
+func Test_deviceService_CreateDevice_KeyParameters(t *testing.T) {
+	tests := []struct {
+		name            string
+		device          *domain.Device
+		wantErr         error
+		expectedKeySize int
+		expectedCurve   string
+	}{
+		{
+			name:            "RSA defaults to 2048 bits",
+			device:          &domain.Device{Algorithm: "RSA"},
+			expectedKeySize: 2048,
+		},
+		{
+			name:            "RSA with 3072 bits",
+			device:          &domain.Device{Algorithm: "RSA", KeySize: 3072},
+			expectedKeySize: 3072,
+		},
+		{
+			name:          "ECC defaults to P-384",
+			device:        &domain.Device{Algorithm: "ECC"},
+			expectedCurve: "P-384",
+		},
+		{
+			name:          "ECC with P-256",
+			device:        &domain.Device{Algorithm: "ECC", Curve: "P-256"},
+			expectedCurve: "P-256",
+		},
+		{
+			name:          "ECC with P-521",
+			device:        &domain.Device{Algorithm: "ECC", Curve: "P-521"},
+			expectedCurve: "P-521",
+		},
+		{
+			name:   "ED25519 without parameters",
+			device: &domain.Device{Algorithm: "ED25519"},
+		},
+		{
+			name:    "ED25519 with curve",
+			device:  &domain.Device{Algorithm: "ED25519", Curve: "P-256"},
+			wantErr: domain.ErrInvalidKeyParameters,
+		},
+		{
+			name:    "RSA with unsupported key size",
+			device:  &domain.Device{Algorithm: "RSA", KeySize: 512},
+			wantErr: domain.ErrInvalidKeyParameters,
+		},
+		{
+			name:    "RSA with curve",
+			device:  &domain.Device{Algorithm: "RSA", Curve: "P-256"},
+			wantErr: domain.ErrInvalidKeyParameters,
+		},
+		{
+			name:    "ECC with unsupported curve",
+			device:  &domain.Device{Algorithm: "ECC", Curve: "P-224"},
+			wantErr: domain.ErrInvalidKeyParameters,
+		},
+		{
+			name:    "ECC with key size",
+			device:  &domain.Device{Algorithm: "ECC", KeySize: 2048},
+			wantErr: domain.ErrInvalidKeyParameters,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// spawn repository
+			repository := persistence.NewInMemoryRepository()
+			transactions := persistence.NewInMemoryTransactionRepository()
+
+			// spawn device service
+			deviceService := service.NewDeviceService(repository, transactions)
+
+			tt.device.ID = uuid.New().String()
+			err := deviceService.CreateDevice(tt.device)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err, "should not fail to create device")
+			assert.Equal(t, tt.expectedKeySize, tt.device.KeySize)
+			assert.Equal(t, tt.expectedCurve, tt.device.Curve)
+
+			// the device signs and verifies with the generated key
+			result, err := deviceService.SignTransaction(tt.device.ID, "COFFEE", "")
+			assert.NoError(t, err, "should not fail to sign transaction")
+
+			verification, err := deviceService.VerifySignature(tt.device.ID, result.SignedData, result.Signature)
+			assert.NoError(t, err, "should not fail to verify signature")
+			assert.True(t, verification.Valid)
+		})
+	}
+}
+
+func Test_deviceService_CreateDevice_SignatureScheme(t *testing.T) {
+	tests := []struct {
+		name               string
+		device             *domain.Device
+		wantErr            error
+		expectedScheme     string
+		expectedSaltLength int
+	}{
+		{
+			name:           "RSA defaults to PKCS1v15",
+			device:         &domain.Device{Algorithm: "RSA"},
+			expectedScheme: "PKCS1v15",
+		},
+		{
+			name:           "RSA with PSS and hash length salt",
+			device:         &domain.Device{Algorithm: "RSA", SignatureScheme: "PSS"},
+			expectedScheme: "PSS",
+		},
+		{
+			name:               "RSA with PSS and explicit salt length",
+			device:             &domain.Device{Algorithm: "RSA", SignatureScheme: "PSS", PSSSaltLength: 64},
+			expectedScheme:     "PSS",
+			expectedSaltLength: 64,
+		},
+		{
+			name:    "RSA with PSS and oversized salt",
+			device:  &domain.Device{Algorithm: "RSA", SignatureScheme: "PSS", PSSSaltLength: 4096},
+			wantErr: domain.ErrInvalidSaltLength,
+		},
+		{
+			name:    "RSA with PKCS1v15 and salt length",
+			device:  &domain.Device{Algorithm: "RSA", SignatureScheme: "PKCS1v15", PSSSaltLength: 32},
+			wantErr: domain.ErrInvalidSaltLength,
+		},
+		{
+			name:    "RSA with unknown scheme",
+			device:  &domain.Device{Algorithm: "RSA", SignatureScheme: "X9.31"},
+			wantErr: domain.ErrInvalidSignatureScheme,
+		},
+		{
+			name:    "ECC with PSS",
+			device:  &domain.Device{Algorithm: "ECC", SignatureScheme: "PSS"},
+			wantErr: domain.ErrInvalidSignatureScheme,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// spawn repository
+			repository := persistence.NewInMemoryRepository()
+			transactions := persistence.NewInMemoryTransactionRepository()
+
+			// spawn device service
+			deviceService := service.NewDeviceService(repository, transactions)
+
+			tt.device.ID = uuid.New().String()
+			err := deviceService.CreateDevice(tt.device)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err, "should not fail to create device")
+			assert.Equal(t, tt.expectedScheme, tt.device.SignatureScheme)
+			assert.Equal(t, tt.expectedSaltLength, tt.device.PSSSaltLength)
+
+			// the device signs and verifies with the selected scheme
+			result, err := deviceService.SignTransaction(tt.device.ID, "COFFEE", "")
+			assert.NoError(t, err, "should not fail to sign transaction")
+
+			verification, err := deviceService.VerifySignature(tt.device.ID, result.SignedData, result.Signature)
+			assert.NoError(t, err, "should not fail to verify signature")
+			assert.True(t, verification.Valid)
+
+			report, err := deviceService.AuditDevice(tt.device.ID)
+			assert.NoError(t, err, "should not fail to audit device")
+			assert.True(t, report.Valid)
+		})
+	}
+}
+
 func Test_deviceService_SignTransaction(t *testing.T) {
 	t.Run("sign single transaction", func(t *testing.T) {
 		// spawn repository
 		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
 
 		// spawn device service
-		deviceService := service.NewDeviceService(repository)
+		deviceService := service.NewDeviceService(repository, transactions)
 
 		// create device
 		id := uuid.New().String()
@@ -84,6 +294,7 @@ func Test_deviceService_SignTransaction(t *testing.T) {
 			PublicKey:        "",
 			SignatureCounter: 0,
 			LastSignature:    "",
+			SignedDataFormat: domain.SignedDataFormatLegacy,
 		}
 
 		err := deviceService.CreateDevice(device)
@@ -93,19 +304,24 @@ func Test_deviceService_SignTransaction(t *testing.T) {
 		trxData := "COFFEE:2025-10-26T07:00:00Z"
 		signData := fmt.Sprintf("%d_%s_%s", device.SignatureCounter, trxData, device.LastSignature)
 
-		result, err := deviceService.SignTransaction(id, trxData)
+		result, err := deviceService.SignTransaction(id, trxData, "")
 
 		assert.NoError(t, err, "should not fail to sign transaction")
 		assert.Equal(t, signData, result.SignedData)
+
+		// the repository keeps its own copy of the device
+		device, err = deviceService.GetDevice(id)
+		assert.NoError(t, err)
 		assert.Equal(t, 1, device.SignatureCounter)
 	})
 
 	t.Run("sign consecutive transactions", func(t *testing.T) {
 		// spawn repository
 		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
 
 		// spawn device service
-		deviceService := service.NewDeviceService(repository)
+		deviceService := service.NewDeviceService(repository, transactions)
 
 		// create device
 		id := uuid.New().String()
@@ -117,6 +333,7 @@ func Test_deviceService_SignTransaction(t *testing.T) {
 			PublicKey:        "",
 			SignatureCounter: 0,
 			LastSignature:    "",
+			SignedDataFormat: domain.SignedDataFormatLegacy,
 		}
 
 		err := deviceService.CreateDevice(device)
@@ -126,29 +343,36 @@ func Test_deviceService_SignTransaction(t *testing.T) {
 		trxData := "COFFEE:2025-10-26T07:00:00Z"
 		signData := fmt.Sprintf("%d_%s_%s", device.SignatureCounter, trxData, device.LastSignature)
 
-		result, err := deviceService.SignTransaction(id, trxData)
+		result, err := deviceService.SignTransaction(id, trxData, "")
 
 		assert.NoError(t, err, "should not fail to sign transaction")
 		assert.Equal(t, signData, result.SignedData)
+
+		device, err = deviceService.GetDevice(id)
+		assert.NoError(t, err)
 		assert.Equal(t, 1, device.SignatureCounter)
 
 		// sign another transaction
 		trxData = "COFFEE:2025-10-26T07:01:00Z"
 		signData = fmt.Sprintf("%d_%s_%s", device.SignatureCounter, trxData, device.LastSignature)
 
-		result, err = deviceService.SignTransaction(id, trxData)
+		result, err = deviceService.SignTransaction(id, trxData, "")
 
 		assert.NoError(t, err, "should not fail to sign transaction")
 		assert.Equal(t, signData, result.SignedData)
+
+		device, err = deviceService.GetDevice(id)
+		assert.NoError(t, err)
 		assert.Equal(t, 2, device.SignatureCounter)
 	})
 
 	t.Run("sign transaction with invalid device", func(t *testing.T) {
 		// spawn repository
 		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
 
 		// spawn device service
-		deviceService := service.NewDeviceService(repository)
+		deviceService := service.NewDeviceService(repository, transactions)
 
 		// create device
 		id := uuid.New().String()
@@ -160,6 +384,7 @@ func Test_deviceService_SignTransaction(t *testing.T) {
 			PublicKey:        "",
 			SignatureCounter: 0,
 			LastSignature:    "",
+			SignedDataFormat: domain.SignedDataFormatLegacy,
 		}
 
 		err := deviceService.CreateDevice(device)
@@ -169,19 +394,23 @@ func Test_deviceService_SignTransaction(t *testing.T) {
 		trxData := "COFFEE:2025-10-26T07:00:00Z"
 		signData := fmt.Sprintf("%d_%s_%s", device.SignatureCounter, trxData, device.LastSignature)
 
-		result, err := deviceService.SignTransaction(id, trxData)
+		result, err := deviceService.SignTransaction(id, trxData, "")
 
 		assert.NoError(t, err, "should not fail to sign transaction")
 		assert.Equal(t, signData, result.SignedData)
+
+		device, err = deviceService.GetDevice(id)
+		assert.NoError(t, err)
 		assert.Equal(t, 1, device.SignatureCounter)
 	})
 
 	t.Run("sign transaction concurrently", func(t *testing.T) {
 		// spawn repository
 		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
 
 		// spawn device service
-		deviceService := service.NewDeviceService(repository)
+		deviceService := service.NewDeviceService(repository, transactions)
 
 		// create device
 		id := uuid.New().String()
@@ -207,7 +436,7 @@ func Test_deviceService_SignTransaction(t *testing.T) {
 				defer wg.Done()
 				// sign a transaction
 				trxData := fmt.Sprintf("COFFEE%d:2025-10-26T07:00:00Z", idx)
-				_, err := deviceService.SignTransaction(id, trxData)
+				_, err := deviceService.SignTransaction(id, trxData, "")
 				assert.NoError(t, err, "should not fail to sign transaction")
 			}(i)
 		}
@@ -224,9 +453,10 @@ func Test_deviceService_SignTransaction(t *testing.T) {
 func Test_deviceService_FindAll(t *testing.T) {
 	// spawn repository
 	repository := persistence.NewInMemoryRepository()
+	transactions := persistence.NewInMemoryTransactionRepository()
 
 	// spawn device service
-	deviceService := service.NewDeviceService(repository)
+	deviceService := service.NewDeviceService(repository, transactions)
 
 	// create device
 	id := uuid.New().String()
@@ -266,9 +496,10 @@ func Test_deviceService_FindAll(t *testing.T) {
 func Test_deviceService_GetDevice(t *testing.T) {
 	// spawn repository
 	repository := persistence.NewInMemoryRepository()
+	transactions := persistence.NewInMemoryTransactionRepository()
 
 	// spawn device service
-	deviceService := service.NewDeviceService(repository)
+	deviceService := service.NewDeviceService(repository, transactions)
 
 	// create device
 	id := uuid.New().String()
@@ -294,3 +525,145 @@ func Test_deviceService_GetDevice(t *testing.T) {
 	assert.NoError(t, err, "should not fail to decode device last signature")
 	assert.Equal(t, id, string(decodedID), "device last signature should match device id")
 }
+
+func Test_deviceService_ListTransactions(t *testing.T) {
+	t.Run("list signature history", func(t *testing.T) {
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions)
+
+		// create device
+		id := uuid.New().String()
+		device := &domain.Device{
+			ID:        id,
+			Algorithm: "ECC",
+			Label:     "device-1",
+		}
+
+		err := deviceService.CreateDevice(device)
+		assert.NoError(t, err, "should not fail to create device")
+
+		// sign two transactions
+		first, err := deviceService.SignTransaction(id, "COFFEE:2025-10-26T07:00:00Z", "")
+		assert.NoError(t, err, "should not fail to sign transaction")
+		second, err := deviceService.SignTransaction(id, "COFFEE:2025-10-26T07:01:00Z", "")
+		assert.NoError(t, err, "should not fail to sign transaction")
+
+		history, err := deviceService.ListTransactions(id)
+		assert.NoError(t, err, "should not fail to list transactions")
+		assert.Equal(t, 2, len(history))
+
+		assert.Equal(t, 0, history[0].Counter)
+		assert.Equal(t, "COFFEE:2025-10-26T07:00:00Z", history[0].Data)
+		assert.Equal(t, first.SignedData, history[0].SignedData)
+		assert.Equal(t, first.Signature, history[0].Signature)
+
+		assert.Equal(t, 1, history[1].Counter)
+		assert.Equal(t, second.SignedData, history[1].SignedData)
+		assert.Equal(t, second.Signature, history[1].Signature)
+
+		transaction, err := deviceService.GetTransaction(id, 1)
+		assert.NoError(t, err, "should not fail to get transaction")
+		assert.Equal(t, second.Signature, transaction.Signature)
+	})
+
+	t.Run("stale tail is not history", func(t *testing.T) {
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+		deviceService := service.NewDeviceService(repository, transactions)
+
+		id := uuid.New().String()
+		err := deviceService.CreateDevice(&domain.Device{ID: id, Algorithm: "ECC"})
+		assert.NoError(t, err, "should not fail to create device")
+
+		_, err = deviceService.SignTransaction(id, "COFFEE", "")
+		assert.NoError(t, err)
+
+		// an entry recorded for a signature whose device write failed
+		err = transactions.Append(&domain.Transaction{DeviceID: id, Counter: 1, Data: "NEVER RETURNED"})
+		assert.NoError(t, err)
+
+		history, err := deviceService.ListTransactions(id)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(history))
+
+		_, err = deviceService.GetTransaction(id, 1)
+		assert.ErrorIs(t, err, domain.ErrTransactionNotFound)
+
+		// the next signature supersedes the stale entry
+		second, err := deviceService.SignTransaction(id, "TEA", "")
+		assert.NoError(t, err)
+
+		transaction, err := deviceService.GetTransaction(id, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, second.Signature, transaction.Signature)
+	})
+
+	t.Run("list signature history of unknown device", func(t *testing.T) {
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions)
+
+		_, err := deviceService.ListTransactions(uuid.New().String())
+		assert.ErrorIs(t, err, domain.ErrDeviceNotFound)
+
+		_, err = deviceService.GetTransaction(uuid.New().String(), 0)
+		assert.ErrorIs(t, err, domain.ErrDeviceNotFound)
+	})
+}
+
+func Test_deviceService_VerifySignature(t *testing.T) {
+	for _, algorithm := range []string{"RSA", "ECC", "ED25519"} {
+		t.Run(fmt.Sprintf("verify %s signature", algorithm), func(t *testing.T) {
+			// spawn repository
+			repository := persistence.NewInMemoryRepository()
+			transactions := persistence.NewInMemoryTransactionRepository()
+
+			// spawn device service
+			deviceService := service.NewDeviceService(repository, transactions)
+
+			// create device
+			id := uuid.New().String()
+			device := &domain.Device{
+				ID:        id,
+				Algorithm: algorithm,
+				Label:     "device-1",
+			}
+
+			err := deviceService.CreateDevice(device)
+			assert.NoError(t, err, "should not fail to create device")
+
+			result, err := deviceService.SignTransaction(id, "COFFEE:2025-10-26T07:00:00Z", "")
+			assert.NoError(t, err, "should not fail to sign transaction")
+
+			verification, err := deviceService.VerifySignature(id, result.SignedData, result.Signature)
+			assert.NoError(t, err, "should not fail to verify signature")
+			assert.True(t, verification.Valid, "signature should be valid")
+
+			verification, err = deviceService.VerifySignature(id, result.SignedData+"X", result.Signature)
+			assert.NoError(t, err, "should not fail to verify tampered data")
+			assert.False(t, verification.Valid, "signature of tampered data should be invalid")
+
+			_, err = deviceService.VerifySignature(id, result.SignedData, "not base64!")
+			assert.ErrorIs(t, err, domain.ErrInvalidSignatureEncoding)
+		})
+	}
+
+	t.Run("verify signature of unknown device", func(t *testing.T) {
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions)
+
+		_, err := deviceService.VerifySignature(uuid.New().String(), "data", "c2lnbmF0dXJl")
+		assert.ErrorIs(t, err, domain.ErrDeviceNotFound)
+	})
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+// SignDigest signs a digest the client computed over data it keeps to itself. The digest is
+// embedded into the device chain as DIGEST:<hash algorithm>:<hex digest> and signed like any
+// other entry, so the signature covers it together with the counter and the previous
+// signature, and the entry is hashed once more for that. The digest is never signed as
+// prehashed input on its own: the signature of a bare digest would equal the signature of
+// any chain entry hashing to it, and would link it to no position in the chain.
+func (s *deviceService) SignDigest(deviceID string, hashAlgorithm string, digest string, idempotencyKey string) (*domain.SignatureResult, error) {
+	data, err := buildDigestData(hashAlgorithm, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.sign(deviceID, data, idempotencyKey)
+}
+
+// buildDigestData validates a hex or base64 encoded digest against the size of its hash
+// algorithm and returns the chain entry data that embeds it.
+func buildDigestData(hashAlgorithm string, digest string) (string, error) {
+	size, ok := crypto.DigestSize(hashAlgorithm)
+	if !ok {
+		return "", domain.ErrInvalidDigestAlgorithm
+	}
+
+	decoded, ok := decodeDigest(digest, size)
+	if !ok {
+		return "", domain.ErrInvalidDigest
+	}
+
+	return fmt.Sprintf("%s:%s:%s", domain.DigestPrefix, hashAlgorithm, hex.EncodeToString(decoded)), nil
+}
+
+// decodeDigest decodes a digest of size bytes. Input made of hex digits only is taken as
+// hex: base64 digests virtually always contain other characters, while the hex encoding
+// of a short digest can be valid base64 of a longer one.
+func decodeDigest(digest string, size int) ([]byte, bool) {
+	if isHex(digest) {
+		decoded, err := hex.DecodeString(digest)
+		return decoded, err == nil && len(decoded) == size
+	}
+
+	// standard or URL alphabet, padded or not
+	unpadded := strings.TrimRight(digest, "=")
+	for _, encoding := range []*base64.Encoding{base64.RawStdEncoding, base64.RawURLEncoding} {
+		decoded, err := encoding.DecodeString(unpadded)
+		if err == nil && len(decoded) == size {
+			return decoded, true
+		}
+	}
+
+	return nil, false
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,123 @@
+package service_test
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_deviceService_SignDigest(t *testing.T) {
+	document := []byte("a large document that never leaves the client")
+	sum := sha256.Sum256(document)
+
+	newDevice := func(t *testing.T, algorithm string) (service.DeviceService, persistence.TransactionRepository, string) {
+		transactions := persistence.NewInMemoryTransactionRepository()
+		deviceService := service.NewDeviceService(persistence.NewInMemoryRepository(), transactions)
+
+		id := uuid.New().String()
+		err := deviceService.CreateDevice(&domain.Device{ID: id, Algorithm: algorithm})
+		assert.NoError(t, err, "should not fail to create device")
+
+		return deviceService, transactions, id
+	}
+
+	t.Run("digest is embedded into the chain", func(t *testing.T) {
+		for _, algorithm := range []string{"RSA", "ECC", "ED25519"} {
+			deviceService, transactions, id := newDevice(t, algorithm)
+
+			result, err := deviceService.SignDigest(id, "SHA-256", hex.EncodeToString(sum[:]), "")
+			assert.NoError(t, err)
+
+			stored, err := transactions.GetByCounter(id, result.Counter)
+			assert.NoError(t, err)
+			assert.Equal(t, "DIGEST:SHA-256:"+hex.EncodeToString(sum[:]), stored.Data)
+
+			verification, err := deviceService.VerifySignature(id, result.SignedData, result.Signature)
+			assert.NoError(t, err)
+			assert.True(t, verification.Valid, algorithm)
+
+			// the chain continues with ordinary entries
+			_, err = deviceService.SignTransaction(id, "COFFEE", "")
+			assert.NoError(t, err)
+
+			report, err := deviceService.AuditDevice(id)
+			assert.NoError(t, err)
+			assert.True(t, report.Valid, algorithm)
+			assert.Equal(t, 2, report.TransactionsChecked)
+		}
+	})
+
+	t.Run("hex and base64 encodings sign the same digest", func(t *testing.T) {
+		deviceService, transactions, id := newDevice(t, "ECC")
+
+		encodings := []string{
+			hex.EncodeToString(sum[:]),
+			base64.StdEncoding.EncodeToString(sum[:]),
+			base64.RawStdEncoding.EncodeToString(sum[:]),
+			base64.RawURLEncoding.EncodeToString(sum[:]),
+		}
+		for i, digest := range encodings {
+			_, err := deviceService.SignDigest(id, "SHA-256", digest, "")
+			assert.NoError(t, err, digest)
+
+			stored, err := transactions.GetByCounter(id, i)
+			assert.NoError(t, err)
+			assert.Equal(t, "DIGEST:SHA-256:"+hex.EncodeToString(sum[:]), stored.Data)
+		}
+
+		sum512 := sha512.Sum512(document)
+		_, err := deviceService.SignDigest(id, "SHA-512", hex.EncodeToString(sum512[:]), "")
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid digests", func(t *testing.T) {
+		deviceService, _, id := newDevice(t, "ECC")
+
+		_, err := deviceService.SignDigest(id, "MD5", hex.EncodeToString(sum[:16]), "")
+		assert.ErrorIs(t, err, domain.ErrInvalidDigestAlgorithm)
+
+		_, err = deviceService.SignDigest(id, "SHA-384", hex.EncodeToString(sum[:]), "")
+		assert.ErrorIs(t, err, domain.ErrInvalidDigest, "a SHA-256 digest is too short for SHA-384")
+
+		_, err = deviceService.SignDigest(id, "SHA-256", "zz"+hex.EncodeToString(sum[1:]), "")
+		assert.ErrorIs(t, err, domain.ErrInvalidDigest)
+
+		_, err = deviceService.SignDigest(id, "SHA-256", "", "")
+		assert.ErrorIs(t, err, domain.ErrInvalidDigest)
+
+		_, err = deviceService.SignDigest(uuid.New().String(), "SHA-256", hex.EncodeToString(sum[:]), "")
+		assert.ErrorIs(t, err, domain.ErrDeviceNotFound)
+	})
+
+	t.Run("digest entries cannot be forged as data", func(t *testing.T) {
+		deviceService, _, id := newDevice(t, "ECC")
+
+		_, err := deviceService.SignTransaction(id, "DIGEST:SHA-256:"+hex.EncodeToString(sum[:]), "")
+		assert.ErrorIs(t, err, domain.ErrReservedData)
+
+		_, err = deviceService.SignTransactions(id, []string{"DIGEST:SHA-256:00"})
+		assert.ErrorIs(t, err, domain.ErrReservedData)
+
+		_, err = deviceService.SignTransaction(id, "DIGESTIVE BISCUITS", "")
+		assert.NoError(t, err, "only the prefix with its separator is reserved")
+	})
+
+	t.Run("retries with an idempotency key", func(t *testing.T) {
+		deviceService, _, id := newDevice(t, "ECC")
+
+		first, err := deviceService.SignDigest(id, "SHA-256", hex.EncodeToString(sum[:]), "upload-1")
+		assert.NoError(t, err)
+
+		retried, err := deviceService.SignDigest(id, "SHA-256", base64.StdEncoding.EncodeToString(sum[:]), "upload-1")
+		assert.NoError(t, err)
+		assert.Equal(t, first, retried, "the same digest in another encoding is the same request")
+	})
+}
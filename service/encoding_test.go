@@ -0,0 +1,86 @@
+package service_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_deviceService_KeyEncoding(t *testing.T) {
+	t.Run("new devices publish standard PKIX public keys", func(t *testing.T) {
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions)
+
+		for _, algorithm := range []string{"RSA", "ECC", "ED25519"} {
+			device := &domain.Device{ID: uuid.New().String(), Algorithm: algorithm}
+			err := deviceService.CreateDevice(device)
+			assert.NoError(t, err, "should not fail to create device")
+
+			block, _ := pem.Decode([]byte(device.PublicKey))
+			assert.NotNil(t, block)
+			assert.Equal(t, "PUBLIC KEY", block.Type)
+
+			_, err = x509.ParsePKIXPublicKey(block.Bytes)
+			assert.NoError(t, err, "%s public key should be SPKI", algorithm)
+		}
+	})
+
+	t.Run("devices with legacy key blocks keep signing", func(t *testing.T) {
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		assert.NoError(t, err)
+		ecdsaKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		assert.NoError(t, err)
+		ecdsaPrivateKey, err := x509.MarshalECPrivateKey(ecdsaKey)
+		assert.NoError(t, err)
+		ecdsaPublicKey, err := x509.MarshalPKIXPublicKey(&ecdsaKey.PublicKey)
+		assert.NoError(t, err)
+
+		// the block types and encodings written by earlier versions of the service
+		legacyDevices := []*domain.Device{
+			{
+				ID:         uuid.New().String(),
+				Algorithm:  "RSA",
+				PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "RSA_PRIVATE_KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})),
+				PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "RSA_PUBLIC_KEY", Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)})),
+			},
+			{
+				ID:         uuid.New().String(),
+				Algorithm:  "ECC",
+				PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE_KEY", Bytes: ecdsaPrivateKey})),
+				PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC_KEY", Bytes: ecdsaPublicKey})),
+			},
+		}
+
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions)
+
+		for _, device := range legacyDevices {
+			assert.NoError(t, repository.Create(device))
+
+			result, err := deviceService.SignTransaction(device.ID, "COFFEE", "")
+			assert.NoError(t, err, "should sign with a legacy %s key", device.Algorithm)
+
+			verification, err := deviceService.VerifySignature(device.ID, result.SignedData, result.Signature)
+			assert.NoError(t, err)
+			assert.True(t, verification.Valid)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package service_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_deviceService_CreateDevice_HashAlgorithm(t *testing.T) {
+	tests := []struct {
+		name    string
+		device  domain.Device
+		want    string
+		wantErr error
+	}{
+		{name: "P-256 defaults to SHA-256", device: domain.Device{Algorithm: "ECC", Curve: "P-256"}, want: "SHA-256"},
+		{name: "P-384 defaults to SHA-384", device: domain.Device{Algorithm: "ECC", Curve: "P-384"}, want: "SHA-384"},
+		{name: "P-521 defaults to SHA-512", device: domain.Device{Algorithm: "ECC", Curve: "P-521"}, want: "SHA-512"},
+		{name: "RSA 2048 defaults to SHA-256", device: domain.Device{Algorithm: "RSA"}, want: "SHA-256"},
+		{name: "RSA 4096 defaults to SHA-384", device: domain.Device{Algorithm: "RSA", KeySize: 4096}, want: "SHA-384"},
+		{name: "Ed25519 uses SHA-512", device: domain.Device{Algorithm: "ED25519"}, want: "SHA-512"},
+		{name: "explicit SHA3-256", device: domain.Device{Algorithm: "ECC", HashAlgorithm: "SHA3-256"}, want: "SHA3-256"},
+		{name: "explicit SHA-512 with PSS", device: domain.Device{Algorithm: "RSA", SignatureScheme: "PSS", HashAlgorithm: "SHA-512"}, want: "SHA-512"},
+		{name: "unknown hash", device: domain.Device{Algorithm: "RSA", HashAlgorithm: "MD5"}, wantErr: domain.ErrInvalidHashAlgorithm},
+		{name: "Ed25519 with another hash", device: domain.Device{Algorithm: "ED25519", HashAlgorithm: "SHA-256"}, wantErr: domain.ErrInvalidHashAlgorithm},
+		{name: "PSS salt too long for the hash", device: domain.Device{Algorithm: "RSA", SignatureScheme: "PSS", PSSSaltLength: 200, HashAlgorithm: "SHA-512"}, wantErr: domain.ErrInvalidSaltLength},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deviceService := service.NewDeviceService(persistence.NewInMemoryRepository(), persistence.NewInMemoryTransactionRepository())
+
+			device := tt.device
+			device.ID = uuid.New().String()
+			err := deviceService.CreateDevice(&device)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err, "should not fail to create device")
+			assert.Equal(t, tt.want, device.HashAlgorithm)
+
+			// signatures verify and the receipt names the hash
+			result, err := deviceService.SignTransaction(device.ID, "COFFEE", "")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, result.HashAlgorithm)
+
+			verification, err := deviceService.VerifySignature(device.ID, result.SignedData, result.Signature)
+			assert.NoError(t, err)
+			assert.True(t, verification.Valid)
+
+			report, err := deviceService.AuditDevice(device.ID)
+			assert.NoError(t, err)
+			assert.True(t, report.Valid)
+		})
+	}
+}
+
+func Test_deviceService_SignTransaction_HashAlgorithm(t *testing.T) {
+	verifyWith := func(t *testing.T, device *domain.Device, signature string, digest []byte) bool {
+		block, _ := pem.Decode([]byte(device.PublicKey))
+		publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		assert.NoError(t, err)
+
+		signatureBytes, err := base64.RawStdEncoding.DecodeString(signature)
+		assert.NoError(t, err)
+
+		return ecdsa.VerifyASN1(publicKey.(*ecdsa.PublicKey), digest, signatureBytes)
+	}
+
+	t.Run("P-384 signs the SHA-384 digest", func(t *testing.T) {
+		deviceService := service.NewDeviceService(persistence.NewInMemoryRepository(), persistence.NewInMemoryTransactionRepository())
+
+		device := &domain.Device{ID: uuid.New().String(), Algorithm: "ECC", Curve: "P-384"}
+		assert.NoError(t, deviceService.CreateDevice(device))
+
+		result, err := deviceService.SignTransaction(device.ID, "COFFEE", "")
+		assert.NoError(t, err)
+
+		digest := sha512.Sum384([]byte(result.SignedData))
+		assert.True(t, verifyWith(t, device, result.Signature, digest[:]))
+	})
+
+	t.Run("devices without a hash keep signing with SHA-256", func(t *testing.T) {
+		repository := persistence.NewInMemoryRepository()
+		deviceService := service.NewDeviceService(repository, persistence.NewInMemoryTransactionRepository())
+
+		device := &domain.Device{ID: uuid.New().String(), Algorithm: "ECC", Curve: "P-384"}
+		assert.NoError(t, deviceService.CreateDevice(device))
+
+		// a device stored before the hash could be chosen
+		_, err := repository.Update(device.ID, func(device *domain.Device) error {
+			device.HashAlgorithm = ""
+			return nil
+		})
+		assert.NoError(t, err)
+
+		result, err := deviceService.SignTransaction(device.ID, "COFFEE", "")
+		assert.NoError(t, err)
+		assert.Equal(t, "SHA-256", result.HashAlgorithm)
+
+		digest := sha256.Sum256([]byte(result.SignedData))
+		assert.True(t, verifyWith(t, device, result.Signature, digest[:]))
+
+		verification, err := deviceService.VerifySignature(device.ID, result.SignedData, result.Signature)
+		assert.NoError(t, err)
+		assert.True(t, verification.Valid)
+	})
+}
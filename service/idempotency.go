@@ -0,0 +1,53 @@
+package service
+
+import (
+	"errors"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+const (
+	// DefaultIdempotencyTTL is how long an idempotency key answers retries of a signing request.
+	DefaultIdempotencyTTL = 24 * time.Hour
+
+	maxIdempotencyKeyLength = 255
+)
+
+// errIdempotentReplay aborts the device update of a retried signing request: the device is
+// left as it is and the original result is returned.
+var errIdempotentReplay = errors.New("idempotent replay")
+
+// validateIdempotencyKey checks the length of a key in characters. An empty key is no key:
+// the request is signed without idempotency.
+func validateIdempotencyKey(key string) error {
+	if utf8.RuneCountInString(key) > maxIdempotencyKeyLength {
+		return domain.ErrInvalidIdempotencyKey
+	}
+
+	return nil
+}
+
+// replay looks up the chain entry an unexpired idempotency key of the device produced.
+// It returns nil if the key is unknown or has expired. An entry beyond the signature
+// counter was never answered, so its key counts as unused.
+func (s *deviceService) replay(device *domain.Device, key string, data string, now time.Time) (*domain.SignatureResult, error) {
+	transaction, err := s.transactions.GetByIdempotencyKey(device.ID, key, now)
+	if err == domain.ErrTransactionNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if transaction.Counter >= device.SignatureCounter {
+		return nil, nil
+	}
+
+	if transaction.Data != data {
+		return nil, domain.ErrIdempotencyKeyReused
+	}
+
+	return signatureResult(transaction), nil
+}
@@ -0,0 +1,134 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_deviceService_SignTransaction_Idempotency(t *testing.T) {
+	t.Run("retry returns the original signature", func(t *testing.T) {
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions)
+
+		id := uuid.New().String()
+		device := &domain.Device{ID: id, Algorithm: "ECC"}
+		err := deviceService.CreateDevice(device)
+		assert.NoError(t, err, "should not fail to create device")
+
+		first, err := deviceService.SignTransaction(id, "COFFEE", "order-1")
+		assert.NoError(t, err, "should not fail to sign transaction")
+
+		retry, err := deviceService.SignTransaction(id, "COFFEE", "order-1")
+		assert.NoError(t, err, "should not fail to replay transaction")
+		assert.Equal(t, first, retry)
+		device, err = deviceService.GetDevice(id)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, device.SignatureCounter, "retry should not advance the counter")
+
+		other, err := deviceService.SignTransaction(id, "COFFEE", "order-2")
+		assert.NoError(t, err, "should not fail to sign transaction")
+		assert.Equal(t, 1, other.Counter)
+
+		history, err := deviceService.ListTransactions(id)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(history))
+	})
+
+	t.Run("key reused with different data", func(t *testing.T) {
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions)
+
+		id := uuid.New().String()
+		err := deviceService.CreateDevice(&domain.Device{ID: id, Algorithm: "ECC"})
+		assert.NoError(t, err, "should not fail to create device")
+
+		_, err = deviceService.SignTransaction(id, "COFFEE", "order-1")
+		assert.NoError(t, err, "should not fail to sign transaction")
+
+		_, err = deviceService.SignTransaction(id, "TEA", "order-1")
+		assert.ErrorIs(t, err, domain.ErrIdempotencyKeyReused)
+
+		_, err = deviceService.SignTransaction(id, "TEA", strings.Repeat("k", 256))
+		assert.ErrorIs(t, err, domain.ErrInvalidIdempotencyKey)
+
+		// the limit counts characters, not bytes
+		_, err = deviceService.SignTransaction(id, "TEA", strings.Repeat("ü", 255))
+		assert.NoError(t, err)
+	})
+
+	t.Run("expired key signs again", func(t *testing.T) {
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions, service.WithIdempotencyTTL(time.Millisecond))
+
+		id := uuid.New().String()
+		err := deviceService.CreateDevice(&domain.Device{ID: id, Algorithm: "ECC"})
+		assert.NoError(t, err, "should not fail to create device")
+
+		_, err = deviceService.SignTransaction(id, "COFFEE", "order-1")
+		assert.NoError(t, err, "should not fail to sign transaction")
+
+		time.Sleep(5 * time.Millisecond)
+
+		again, err := deviceService.SignTransaction(id, "COFFEE", "order-1")
+		assert.NoError(t, err, "should not fail to sign transaction")
+		assert.Equal(t, 1, again.Counter, "expired key should not be replayed")
+
+		history, err := deviceService.ListTransactions(id)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(history))
+	})
+
+	t.Run("keys survive a restart", func(t *testing.T) {
+		dir := t.TempDir()
+
+		// spawn repository
+		repository, err := persistence.NewFileRepository(dir)
+		assert.NoError(t, err)
+		transactions, err := persistence.NewFileTransactionRepository(dir)
+		assert.NoError(t, err)
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions)
+
+		id := uuid.New().String()
+		err = deviceService.CreateDevice(&domain.Device{ID: id, Algorithm: "ECC"})
+		assert.NoError(t, err, "should not fail to create device")
+
+		first, err := deviceService.SignTransaction(id, "COFFEE", "order-1")
+		assert.NoError(t, err, "should not fail to sign transaction")
+		assert.NoError(t, repository.Close())
+		assert.NoError(t, transactions.Close())
+
+		repository, err = persistence.NewFileRepository(dir)
+		assert.NoError(t, err)
+		defer repository.Close()
+		transactions, err = persistence.NewFileTransactionRepository(dir)
+		assert.NoError(t, err)
+		defer transactions.Close()
+
+		deviceService = service.NewDeviceService(repository, transactions)
+
+		retry, err := deviceService.SignTransaction(id, "COFFEE", "order-1")
+		assert.NoError(t, err, "should not fail to replay transaction")
+		assert.Equal(t, first, retry)
+	})
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
+)
+
+// RewrapPrivateKeys re-encrypts the private keys that devices created before the
+// introduction of key stores carry under the current master key of encrypter, which must
+// still know the master key the devices are wrapped under. Plaintext keys left behind by
+// even earlier versions of the service are wrapped as well. Keys held by a key store are
+// not touched. It returns the number of re-wrapped devices.
+func RewrapPrivateKeys(repository persistence.Repository, encrypter *crypto.KeyEncrypter) (int, error) {
+	devices, err := repository.FindAll()
+	if err != nil {
+		return 0, err
+	}
+
+	rewrapped := 0
+	for _, device := range devices {
+		if device.PrivateKey == "" {
+			continue
+		}
+
+		_, err := repository.Update(device.ID, func(device *domain.Device) error {
+			privateKey, err := encrypter.Rewrap(device.PrivateKey, []byte(device.ID))
+			if err != nil {
+				return err
+			}
+
+			device.PrivateKey = privateKey
+			return nil
+		})
+		if err != nil {
+			return rewrapped, err
+		}
+
+		rewrapped++
+	}
+
+	return rewrapped, nil
+}
@@ -0,0 +1,222 @@
+package service_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func newKeyEncrypter(t *testing.T, previous ...[]byte) (*crypto.KeyEncrypter, []byte) {
+	masterKey, err := crypto.GenerateMasterKey()
+	assert.NoError(t, err)
+
+	keyEncrypter, err := crypto.NewKeyEncrypter(masterKey, previous...)
+	assert.NoError(t, err)
+
+	return keyEncrypter, masterKey
+}
+
+func Test_deviceService_KeyStore(t *testing.T) {
+	t.Run("private key is held by the key store", func(t *testing.T) {
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions)
+
+		id := uuid.New().String()
+		err := deviceService.CreateDevice(&domain.Device{ID: id, Algorithm: "ECC"})
+		assert.NoError(t, err, "should not fail to create device")
+
+		stored, err := repository.GetByID(id)
+		assert.NoError(t, err)
+		assert.Empty(t, stored.PrivateKey, "device should not carry key material")
+		assert.NotEmpty(t, stored.KeyHandle, "device should reference its key")
+
+		_, err = deviceService.SignTransaction(id, "COFFEE", "")
+		assert.NoError(t, err, "should not fail to sign transaction")
+	})
+
+	t.Run("file key store wraps keys at rest and outlives the service", func(t *testing.T) {
+		dir := t.TempDir()
+		keyEncrypter, _ := newKeyEncrypter(t)
+
+		keyStore, err := crypto.NewFileKeyStore(dir, keyEncrypter)
+		assert.NoError(t, err)
+
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions, service.WithKeyStore(keyStore))
+
+		id := uuid.New().String()
+		err = deviceService.CreateDevice(&domain.Device{ID: id, Algorithm: "RSA"})
+		assert.NoError(t, err, "should not fail to create device")
+
+		stored, err := repository.GetByID(id)
+		assert.NoError(t, err)
+
+		keyFile, err := os.ReadFile(filepath.Join(dir, stored.KeyHandle+".key"))
+		assert.NoError(t, err, "key file should exist")
+		assert.NotContains(t, string(keyFile), "PRIVATE", "key file should not hold a plaintext key")
+
+		// a new key store instance under the same master key signs with the same key
+		reopened, err := crypto.NewFileKeyStore(dir, keyEncrypter)
+		assert.NoError(t, err)
+
+		deviceService = service.NewDeviceService(repository, transactions, service.WithKeyStore(reopened))
+		result, err := deviceService.SignTransaction(id, "COFFEE", "")
+		assert.NoError(t, err, "should not fail to sign transaction")
+
+		verification, err := deviceService.VerifySignature(id, result.SignedData, result.Signature)
+		assert.NoError(t, err)
+		assert.True(t, verification.Valid)
+
+		// a key store under another master key cannot use the key
+		otherKeyEncrypter, _ := newKeyEncrypter(t)
+		other, err := crypto.NewFileKeyStore(dir, otherKeyEncrypter)
+		assert.NoError(t, err)
+
+		deviceService = service.NewDeviceService(repository, transactions, service.WithKeyStore(other))
+		_, err = deviceService.SignTransaction(id, "COFFEE", "")
+		assert.ErrorIs(t, err, domain.ErrUnknownMasterKey)
+	})
+
+	t.Run("failed create does not leave a key behind", func(t *testing.T) {
+		dir := t.TempDir()
+		keyEncrypter, _ := newKeyEncrypter(t)
+
+		keyStore, err := crypto.NewFileKeyStore(dir, keyEncrypter)
+		assert.NoError(t, err)
+
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions, service.WithKeyStore(keyStore))
+
+		id := uuid.New().String()
+		err = deviceService.CreateDevice(&domain.Device{ID: id, Algorithm: "ECC"})
+		assert.NoError(t, err, "should not fail to create device")
+
+		err = deviceService.CreateDevice(&domain.Device{ID: id, Algorithm: "ECC"})
+		assert.ErrorIs(t, err, domain.ErrDeviceAlreadyExists)
+
+		entries, err := os.ReadDir(dir)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(entries), "only the key of the first device should remain")
+	})
+
+	t.Run("legacy device with wrapped private key", func(t *testing.T) {
+		keyEncrypter, _ := newKeyEncrypter(t)
+
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		id := createLegacyDevice(t, repository, keyEncrypter)
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions, service.WithKeyEncrypter(keyEncrypter))
+
+		_, err := deviceService.SignTransaction(id, "COFFEE", "")
+		assert.NoError(t, err, "should sign with the key carried by the device")
+
+		// without the master key the legacy key cannot be unwrapped
+		deviceService = service.NewDeviceService(repository, transactions)
+		_, err = deviceService.SignTransaction(id, "COFFEE", "")
+		assert.ErrorIs(t, err, domain.ErrUnknownMasterKey)
+	})
+}
+
+func TestMasterKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+	oldKeyEncrypter, oldMasterKey := newKeyEncrypter(t)
+
+	keyStore, err := crypto.NewFileKeyStore(dir, oldKeyEncrypter)
+	assert.NoError(t, err)
+
+	// spawn repository
+	repository := persistence.NewInMemoryRepository()
+	transactions := persistence.NewInMemoryTransactionRepository()
+
+	// spawn device service under the old master key
+	deviceService := service.NewDeviceService(repository, transactions,
+		service.WithKeyEncrypter(oldKeyEncrypter),
+		service.WithKeyStore(keyStore),
+	)
+
+	ids := []string{uuid.New().String(), uuid.New().String()}
+	for _, id := range ids {
+		err := deviceService.CreateDevice(&domain.Device{ID: id, Algorithm: "ECC"})
+		assert.NoError(t, err, "should not fail to create device")
+	}
+
+	legacyID := createLegacyDevice(t, repository, oldKeyEncrypter)
+
+	// re-wrap the key store and the legacy device under a new master key
+	rotatingKeyEncrypter, newMasterKey := newKeyEncrypter(t, oldMasterKey)
+
+	rotatingKeyStore, err := crypto.NewFileKeyStore(dir, rotatingKeyEncrypter)
+	assert.NoError(t, err)
+
+	rewrapped, err := rotatingKeyStore.Rewrap()
+	assert.NoError(t, err, "should not fail to re-wrap key store")
+	assert.Equal(t, 2, rewrapped)
+
+	rewrapped, err = service.RewrapPrivateKeys(repository, rotatingKeyEncrypter)
+	assert.NoError(t, err, "should not fail to re-wrap private keys")
+	assert.Equal(t, 1, rewrapped)
+
+	// the devices sign under the new master key only
+	newKeyEncrypter, err := crypto.NewKeyEncrypter(newMasterKey)
+	assert.NoError(t, err)
+	newKeyStore, err := crypto.NewFileKeyStore(dir, newKeyEncrypter)
+	assert.NoError(t, err)
+
+	deviceService = service.NewDeviceService(repository, transactions,
+		service.WithKeyEncrypter(newKeyEncrypter),
+		service.WithKeyStore(newKeyStore),
+	)
+	for _, id := range append(ids, legacyID) {
+		_, err := deviceService.SignTransaction(id, "COFFEE", "")
+		assert.NoError(t, err, "should sign after rotation")
+	}
+}
+
+// createLegacyDevice stores a device that carries its own wrapped private key, as devices
+// created before the introduction of key stores do.
+func createLegacyDevice(t *testing.T, repository persistence.Repository, keyEncrypter *crypto.KeyEncrypter) string {
+	id := uuid.New().String()
+
+	generator, err := crypto.NewGenerator("ECC", crypto.KeyParameters{})
+	assert.NoError(t, err)
+	keyPair, err := generator.Generate()
+	assert.NoError(t, err)
+
+	privateKey, err := keyEncrypter.Wrap(keyPair.GetPrivateKeyPEM(), []byte(id))
+	assert.NoError(t, err)
+
+	err = repository.Create(&domain.Device{
+		ID:            id,
+		Algorithm:     "ECC",
+		Curve:         "P-384",
+		PrivateKey:    privateKey,
+		PublicKey:     string(keyPair.GetPublicKeyPEM()),
+		LastSignature: "bGVnYWN5",
+	})
+	assert.NoError(t, err)
+
+	return id
+}
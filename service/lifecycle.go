@@ -0,0 +1,75 @@
+package service
+
+import (
+	"time"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+// statusTransitions lists the lifecycle states each state can move to.
+// Decommissioned devices cannot move anywhere.
+var statusTransitions = map[string][]string{
+	domain.DeviceStatusActive:   {domain.DeviceStatusDisabled, domain.DeviceStatusDecommissioned},
+	domain.DeviceStatusDisabled: {domain.DeviceStatusActive, domain.DeviceStatusDecommissioned},
+}
+
+// ChangeDeviceStatus moves the device to another lifecycle state and records the transition.
+// Decommissioning a device also destroys its private key; its signatures stay verifiable
+// through the public key.
+func (s *deviceService) ChangeDeviceStatus(deviceID string, status string, reason string) (*domain.Device, error) {
+	switch status {
+	case domain.DeviceStatusActive, domain.DeviceStatusDisabled, domain.DeviceStatusDecommissioned:
+	default:
+		return nil, domain.ErrInvalidStatus
+	}
+
+	var destroyedKeyHandle string
+	device, err := s.repository.Update(deviceID, func(device *domain.Device) error {
+		from := device.CurrentStatus()
+		if !canTransition(from, status) {
+			return domain.ErrInvalidStatusTransition
+		}
+
+		device.Status = status
+		device.StatusHistory = append(device.StatusHistory, domain.StatusChange{
+			From:      from,
+			To:        status,
+			Reason:    reason,
+			ChangedAt: time.Now(),
+		})
+
+		if status == domain.DeviceStatusDecommissioned {
+			destroyedKeyHandle = device.KeyHandle
+			device.KeyHandle = ""
+			device.PrivateKey = ""
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if status == domain.DeviceStatusDecommissioned && s.signers != nil {
+		s.signers.invalidate(deviceID)
+	}
+
+	// the key is only destroyed once the device can no longer reference it
+	if destroyedKeyHandle != "" {
+		if err := s.keyStore.Delete(destroyedKeyHandle); err != nil && err != domain.ErrKeyNotFound {
+			return nil, err
+		}
+	}
+
+	return device, nil
+}
+
+func canTransition(from string, to string) bool {
+	for _, allowed := range statusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,120 @@
+package service_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_deviceService_ChangeDeviceStatus(t *testing.T) {
+	t.Run("deactivate and reactivate device", func(t *testing.T) {
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions)
+
+		id := uuid.New().String()
+		device := &domain.Device{ID: id, Algorithm: "ECC"}
+		err := deviceService.CreateDevice(device)
+		assert.NoError(t, err, "should not fail to create device")
+		assert.Equal(t, "ACTIVE", device.Status)
+
+		_, err = deviceService.ChangeDeviceStatus(id, "DISABLED", "device reported lost")
+		assert.NoError(t, err, "should not fail to deactivate device")
+
+		_, err = deviceService.SignTransaction(id, "COFFEE", "")
+		assert.ErrorIs(t, err, domain.ErrDeviceNotActive, "disabled device should not sign")
+
+		updated, err := deviceService.ChangeDeviceStatus(id, "ACTIVE", "device found")
+		assert.NoError(t, err, "should not fail to reactivate device")
+		assert.Equal(t, "ACTIVE", updated.Status)
+
+		assert.Equal(t, 2, len(updated.StatusHistory))
+		assert.Equal(t, "ACTIVE", updated.StatusHistory[0].From)
+		assert.Equal(t, "DISABLED", updated.StatusHistory[0].To)
+		assert.Equal(t, "device reported lost", updated.StatusHistory[0].Reason)
+		assert.False(t, updated.StatusHistory[0].ChangedAt.IsZero())
+		assert.Equal(t, "device found", updated.StatusHistory[1].Reason)
+
+		result, err := deviceService.SignTransaction(id, "COFFEE", "")
+		assert.NoError(t, err, "reactivated device should sign")
+		device, err = deviceService.GetDevice(id)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, device.SignatureCounter, "rejected signature should not advance the counter")
+		assert.NotEmpty(t, result.Signature)
+	})
+
+	t.Run("decommission device", func(t *testing.T) {
+		dir := t.TempDir()
+		keyEncrypter, _ := newKeyEncrypter(t)
+
+		keyStore, err := crypto.NewFileKeyStore(dir, keyEncrypter)
+		assert.NoError(t, err)
+
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions, service.WithKeyStore(keyStore))
+
+		id := uuid.New().String()
+		err = deviceService.CreateDevice(&domain.Device{ID: id, Algorithm: "ECC"})
+		assert.NoError(t, err, "should not fail to create device")
+
+		result, err := deviceService.SignTransaction(id, "COFFEE", "")
+		assert.NoError(t, err, "should not fail to sign transaction")
+
+		_, err = deviceService.ChangeDeviceStatus(id, "DECOMMISSIONED", "end of life")
+		assert.NoError(t, err, "should not fail to decommission device")
+
+		entries, err := os.ReadDir(dir)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(entries), "private key should be destroyed")
+
+		_, err = deviceService.SignTransaction(id, "COFFEE", "")
+		assert.ErrorIs(t, err, domain.ErrDeviceNotActive)
+
+		_, err = deviceService.ChangeDeviceStatus(id, "ACTIVE", "")
+		assert.ErrorIs(t, err, domain.ErrInvalidStatusTransition, "decommissioning should be final")
+
+		// the signatures of a decommissioned device stay verifiable
+		verification, err := deviceService.VerifySignature(id, result.SignedData, result.Signature)
+		assert.NoError(t, err)
+		assert.True(t, verification.Valid)
+
+		report, err := deviceService.AuditDevice(id)
+		assert.NoError(t, err)
+		assert.True(t, report.Valid)
+	})
+
+	t.Run("invalid transitions", func(t *testing.T) {
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions)
+
+		id := uuid.New().String()
+		err := deviceService.CreateDevice(&domain.Device{ID: id, Algorithm: "ECC"})
+		assert.NoError(t, err, "should not fail to create device")
+
+		_, err = deviceService.ChangeDeviceStatus(id, "ACTIVE", "")
+		assert.ErrorIs(t, err, domain.ErrInvalidStatusTransition, "active device cannot be activated")
+
+		_, err = deviceService.ChangeDeviceStatus(id, "SUSPENDED", "")
+		assert.ErrorIs(t, err, domain.ErrInvalidStatus)
+
+		_, err = deviceService.ChangeDeviceStatus(uuid.New().String(), "DISABLED", "")
+		assert.ErrorIs(t, err, domain.ErrDeviceNotFound)
+	})
+}
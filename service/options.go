@@ -0,0 +1,43 @@
+package service
+
+import (
+	"time"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+)
+
+// Option configures an optional dependency of the device service.
+type Option func(*deviceService)
+
+// WithKeyEncrypter unwraps the private keys that devices created before the introduction
+// of key stores carry, under the master keys of encrypter.
+func WithKeyEncrypter(encrypter *crypto.KeyEncrypter) Option {
+	return func(s *deviceService) {
+		s.keyEncrypter = encrypter
+	}
+}
+
+// WithKeyStore keeps the private keys of new devices in keyStore. Without it, keys are
+// held by an in-process software key store, which only suits repositories that do not
+// outlive the process.
+func WithKeyStore(keyStore crypto.KeyStore) Option {
+	return func(s *deviceService) {
+		s.keyStore = keyStore
+	}
+}
+
+// WithIdempotencyTTL sets how long an idempotency key answers retries of a signing request.
+// It defaults to DefaultIdempotencyTTL.
+func WithIdempotencyTTL(ttl time.Duration) Option {
+	return func(s *deviceService) {
+		s.idempotencyTTL = ttl
+	}
+}
+
+// WithoutSignerCache makes the service fetch the signer from the key store for every
+// signature instead of keeping the parsed signer of each device in memory.
+func WithoutSignerCache() Option {
+	return func(s *deviceService) {
+		s.signers = nil
+	}
+}
@@ -0,0 +1,99 @@
+package service_test
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_deviceService_SignatureReceipt(t *testing.T) {
+	t.Run("receipt describes the signature", func(t *testing.T) {
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+		deviceService := service.NewDeviceService(repository, transactions)
+
+		device := &domain.Device{ID: uuid.New().String(), Algorithm: "RSA", SignatureScheme: "PSS"}
+		err := deviceService.CreateDevice(device)
+		assert.NoError(t, err, "should not fail to create device")
+
+		before := time.Now()
+		first, err := deviceService.SignTransaction(device.ID, "COFFEE", "")
+		assert.NoError(t, err)
+
+		assert.Equal(t, device.ID, first.DeviceID)
+		assert.Equal(t, 0, first.Counter)
+		assert.Equal(t, "RSA", first.Algorithm)
+		assert.Equal(t, "PSS", first.SignatureScheme)
+		assert.Equal(t, "SHA-256", first.HashAlgorithm)
+		assert.Equal(t, 1, first.KeyVersion)
+		assert.False(t, first.Timestamp.Before(before.Round(0)))
+		assert.Equal(t, base64.RawStdEncoding.EncodeToString([]byte(device.ID)), first.PreviousSignature, "the chain starts from the genesis value")
+
+		results, err := deviceService.SignTransactions(device.ID, []string{"TEA", "CAKE"})
+		assert.NoError(t, err)
+		assert.Equal(t, first.Signature, results[0].PreviousSignature)
+		assert.Equal(t, results[0].Signature, results[1].PreviousSignature)
+		assert.Equal(t, 2, results[1].Counter)
+
+		_, err = deviceService.RotateKey(device.ID)
+		assert.NoError(t, err)
+
+		afterRotation, err := deviceService.SignTransaction(device.ID, "COFFEE", "")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, afterRotation.KeyVersion)
+
+		rollover, err := deviceService.GetTransaction(device.ID, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, rollover.KeyVersion, "the rollover entry is signed with the retiring key")
+	})
+
+	t.Run("Ed25519 hashes with SHA-512", func(t *testing.T) {
+		deviceService := service.NewDeviceService(persistence.NewInMemoryRepository(), persistence.NewInMemoryTransactionRepository())
+
+		device := &domain.Device{ID: uuid.New().String(), Algorithm: "ED25519"}
+		assert.NoError(t, deviceService.CreateDevice(device))
+
+		result, err := deviceService.SignTransaction(device.ID, "COFFEE", "")
+		assert.NoError(t, err)
+		assert.Equal(t, "ED25519", result.Algorithm)
+		assert.Empty(t, result.SignatureScheme)
+		assert.Equal(t, "SHA-512", result.HashAlgorithm)
+	})
+
+	t.Run("receipt is persisted in the history", func(t *testing.T) {
+		dir := t.TempDir()
+
+		repository := persistence.NewInMemoryRepository()
+		transactions, err := persistence.NewFileTransactionRepository(dir)
+		assert.NoError(t, err)
+		deviceService := service.NewDeviceService(repository, transactions)
+
+		device := &domain.Device{ID: uuid.New().String(), Algorithm: "ECC"}
+		assert.NoError(t, deviceService.CreateDevice(device))
+
+		result, err := deviceService.SignTransaction(device.ID, "COFFEE", "")
+		assert.NoError(t, err)
+		assert.NoError(t, transactions.Close())
+
+		reopened, err := persistence.NewFileTransactionRepository(dir)
+		assert.NoError(t, err)
+		defer reopened.Close()
+
+		stored, err := reopened.GetByCounter(device.ID, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, result.DeviceID, stored.DeviceID)
+		assert.Equal(t, result.Algorithm, stored.Algorithm)
+		assert.Equal(t, result.HashAlgorithm, stored.HashAlgorithm)
+		assert.Equal(t, result.KeyVersion, stored.KeyVersion)
+		assert.Equal(t, result.PreviousSignature, stored.PreviousSignature)
+		assert.Equal(t, result.Signature, stored.Signature)
+		assert.Equal(t, result.SignedData, stored.SignedData)
+		assert.True(t, result.Timestamp.Equal(stored.CreatedAt))
+	})
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+// RotateKey replaces the key pair of a device. The retiring key signs a rollover entry
+// that carries the fingerprint of the new public key, so the new key is linked into the
+// signature chain by the key it replaces. Retired public keys stay in the key history of
+// the device, which keeps the signatures they made verifiable.
+func (s *deviceService) RotateKey(deviceID string) (*domain.Device, error) {
+	current, err := s.repository.GetByID(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// generating a key can take a while, so it happens before the device is locked
+	key, err := s.keyStore.Generate(current.Algorithm, crypto.KeyParameters{
+		KeySize: current.KeySize,
+		Curve:   current.Curve,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint, err := crypto.PublicKeyFingerprint(key.PublicKeyPEM)
+	if err != nil {
+		_ = s.keyStore.Delete(key.Handle)
+		return nil, err
+	}
+
+	var retiredKeyHandle string
+	device, err := s.repository.Update(deviceID, func(device *domain.Device) error {
+		if device.CurrentStatus() != domain.DeviceStatusActive {
+			return domain.ErrDeviceNotActive
+		}
+
+		signer, err := s.signer(device)
+		if err != nil {
+			return err
+		}
+
+		keys := device.Keys()
+		version := keys[len(keys)-1].Version + 1
+		counter := device.SignatureCounter
+
+		now := time.Now()
+		rollover, err := signEntry(signer, device, counter, buildRolloverData(version, fingerprint), device.LastSignature, now)
+		if err != nil {
+			return err
+		}
+
+		if err := s.transactions.Append(rollover); err != nil {
+			return err
+		}
+
+		// the retiring key signed the rollover, so its range ends with it
+		history := make([]domain.DeviceKey, len(keys), len(keys)+1)
+		copy(history, keys)
+		history[len(history)-1].ValidUntilCounter = &counter
+		history[len(history)-1].RetiredAt = &now
+		history = append(history, domain.DeviceKey{
+			Version:          version,
+			PublicKey:        string(key.PublicKeyPEM),
+			ValidFromCounter: counter + 1,
+			CreatedAt:        now,
+		})
+
+		retiredKeyHandle = device.KeyHandle
+		device.KeyHandle = key.Handle
+		device.PrivateKey = ""
+		device.PublicKey = string(key.PublicKeyPEM)
+		device.KeyVersion = version
+		device.KeyHistory = history
+		device.SignatureCounter++
+		device.LastSignature = rollover.Signature
+
+		return nil
+	})
+	if err != nil {
+		_ = s.keyStore.Delete(key.Handle)
+		return nil, err
+	}
+
+	if s.signers != nil {
+		s.signers.invalidate(deviceID)
+	}
+
+	// the retired key is only destroyed once the device no longer references it
+	if retiredKeyHandle != "" {
+		if err := s.keyStore.Delete(retiredKeyHandle); err != nil && err != domain.ErrKeyNotFound {
+			return nil, err
+		}
+	}
+
+	return device, nil
+}
+
+// buildRolloverData is the data of the chain entry that introduces key version to the chain:
+// KEY_ROLLOVER:<version>:<fingerprint of the new public key>.
+func buildRolloverData(version int, fingerprint string) string {
+	return fmt.Sprintf("%s:%d:%s", domain.KeyRolloverPrefix, version, fingerprint)
+}
+
+// parseRolloverData splits rollover data into the key version and fingerprint it announces.
+// ok is false if data is not a rollover entry.
+func parseRolloverData(data string) (version int, fingerprint string, ok bool) {
+	rest, found := strings.CutPrefix(data, domain.KeyRolloverPrefix+":")
+	if !found {
+		return 0, "", false
+	}
+
+	versionPart, fingerprint, found := strings.Cut(rest, ":")
+	if !found {
+		return 0, "", false
+	}
+
+	version, err := strconv.Atoi(versionPart)
+	if err != nil {
+		return 0, "", false
+	}
+
+	return version, fingerprint, true
+}
+
+// isReservedData reports whether data would pass for an entry the service writes itself.
+func isReservedData(data string) bool {
+	return strings.HasPrefix(data, domain.KeyRolloverPrefix) || strings.HasPrefix(data, domain.DigestPrefix+":") ||
+		strings.HasPrefix(data, domain.UploadPrefix+":")
+}
@@ -0,0 +1,184 @@
+package service_test
+
+import (
+	"encoding/base64"
+	"os"
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_deviceService_RotateKey(t *testing.T) {
+	t.Run("rotation links the new key into the chain", func(t *testing.T) {
+		dir := t.TempDir()
+		keyEncrypter, _ := newKeyEncrypter(t)
+
+		keyStore, err := crypto.NewFileKeyStore(dir, keyEncrypter)
+		assert.NoError(t, err)
+
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions, service.WithKeyStore(keyStore))
+
+		id := uuid.New().String()
+		device := &domain.Device{ID: id, Algorithm: "RSA", SignatureScheme: "PSS"}
+		err = deviceService.CreateDevice(device)
+		assert.NoError(t, err, "should not fail to create device")
+		assert.Equal(t, 1, device.KeyVersion)
+		assert.Equal(t, 1, len(device.KeyHistory))
+		oldPublicKey := device.PublicKey
+
+		before, err := deviceService.SignTransaction(id, "COFFEE", "")
+		assert.NoError(t, err, "should not fail to sign transaction")
+
+		rotated, err := deviceService.RotateKey(id)
+		assert.NoError(t, err, "should not fail to rotate key")
+		assert.Equal(t, 2, rotated.KeyVersion)
+		assert.Equal(t, 2, rotated.SignatureCounter, "rollover should take a place in the chain")
+		assert.NotEqual(t, oldPublicKey, rotated.PublicKey)
+
+		assert.Equal(t, 2, len(rotated.KeyHistory))
+		assert.Equal(t, oldPublicKey, rotated.KeyHistory[0].PublicKey)
+		assert.Equal(t, 1, *rotated.KeyHistory[0].ValidUntilCounter)
+		assert.NotNil(t, rotated.KeyHistory[0].RetiredAt)
+		assert.Equal(t, 2, rotated.KeyHistory[1].ValidFromCounter)
+		assert.Nil(t, rotated.KeyHistory[1].ValidUntilCounter)
+
+		entries, err := os.ReadDir(dir)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(entries), "retired private key should be destroyed")
+
+		rollover, err := deviceService.GetTransaction(id, 1)
+		assert.NoError(t, err)
+		assert.Contains(t, rollover.Data, "KEY_ROLLOVER:2:")
+
+		after, err := deviceService.SignTransaction(id, "TEA", "")
+		assert.NoError(t, err, "should sign with the new key")
+
+		// signatures of both keys stay verifiable
+		for _, result := range []*domain.SignatureResult{before, after} {
+			verification, err := deviceService.VerifySignature(id, result.SignedData, result.Signature)
+			assert.NoError(t, err)
+			assert.True(t, verification.Valid)
+		}
+
+		report, err := deviceService.AuditDevice(id)
+		assert.NoError(t, err)
+		assert.True(t, report.Valid)
+		assert.Equal(t, 3, report.TransactionsChecked)
+	})
+
+	t.Run("rotate legacy device", func(t *testing.T) {
+		keyEncrypter, _ := newKeyEncrypter(t)
+
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		id := createLegacyDevice(t, repository, keyEncrypter)
+
+		// let the chain of the legacy device start at the genesis signature, so it can be audited
+		_, err := repository.Update(id, func(device *domain.Device) error {
+			device.LastSignature = base64.RawStdEncoding.EncodeToString([]byte(id))
+			return nil
+		})
+		assert.NoError(t, err)
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions, service.WithKeyEncrypter(keyEncrypter))
+
+		rotated, err := deviceService.RotateKey(id)
+		assert.NoError(t, err, "should not fail to rotate key")
+		assert.Equal(t, 2, rotated.KeyVersion)
+		assert.Empty(t, rotated.PrivateKey, "legacy key should be dropped")
+		assert.NotEmpty(t, rotated.KeyHandle)
+
+		_, err = deviceService.SignTransaction(id, "COFFEE", "")
+		assert.NoError(t, err)
+
+		report, err := deviceService.AuditDevice(id)
+		assert.NoError(t, err)
+		assert.True(t, report.Valid)
+	})
+
+	t.Run("audit detects a replaced key", func(t *testing.T) {
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions)
+
+		id := uuid.New().String()
+		err := deviceService.CreateDevice(&domain.Device{ID: id, Algorithm: "ECC"})
+		assert.NoError(t, err, "should not fail to create device")
+
+		_, err = deviceService.RotateKey(id)
+		assert.NoError(t, err, "should not fail to rotate key")
+
+		// swap the announced key for one the chain never linked
+		generator, err := crypto.NewGenerator("ECC", crypto.KeyParameters{})
+		assert.NoError(t, err)
+		keyPair, err := generator.Generate()
+		assert.NoError(t, err)
+
+		_, err = repository.Update(id, func(device *domain.Device) error {
+			device.KeyHistory[1].PublicKey = string(keyPair.GetPublicKeyPEM())
+			device.PublicKey = device.KeyHistory[1].PublicKey
+			return nil
+		})
+		assert.NoError(t, err)
+
+		report, err := deviceService.AuditDevice(id)
+		assert.NoError(t, err)
+		assert.False(t, report.Valid)
+		assert.Equal(t, 0, report.BrokenLink.Counter)
+		assert.Contains(t, report.BrokenLink.Reason, "fingerprint")
+	})
+
+	t.Run("disabled device cannot rotate", func(t *testing.T) {
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions)
+
+		id := uuid.New().String()
+		err := deviceService.CreateDevice(&domain.Device{ID: id, Algorithm: "ECC"})
+		assert.NoError(t, err, "should not fail to create device")
+
+		_, err = deviceService.ChangeDeviceStatus(id, "DISABLED", "")
+		assert.NoError(t, err)
+
+		_, err = deviceService.RotateKey(id)
+		assert.ErrorIs(t, err, domain.ErrDeviceNotActive)
+
+		_, err = deviceService.RotateKey(uuid.New().String())
+		assert.ErrorIs(t, err, domain.ErrDeviceNotFound)
+	})
+
+	t.Run("rollover entries cannot be forged", func(t *testing.T) {
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions)
+
+		id := uuid.New().String()
+		err := deviceService.CreateDevice(&domain.Device{ID: id, Algorithm: "ECC"})
+		assert.NoError(t, err, "should not fail to create device")
+
+		_, err = deviceService.SignTransaction(id, "KEY_ROLLOVER:2:abcd", "")
+		assert.ErrorIs(t, err, domain.ErrReservedData)
+	})
+}
@@ -0,0 +1,111 @@
+package service_test
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_deviceService_SignedDataFormat(t *testing.T) {
+	newDevice := func(t *testing.T, format string) (service.DeviceService, persistence.TransactionRepository, *domain.Device) {
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+		deviceService := service.NewDeviceService(repository, transactions)
+
+		device := &domain.Device{ID: uuid.New().String(), Algorithm: "ECC", SignedDataFormat: format}
+		err := deviceService.CreateDevice(device)
+		assert.NoError(t, err, "should not fail to create device")
+
+		return deviceService, transactions, device
+	}
+
+	t.Run("V1 format by default", func(t *testing.T) {
+		deviceService, _, device := newDevice(t, "")
+		assert.Equal(t, domain.SignedDataFormatV1, device.SignedDataFormat)
+
+		result, err := deviceService.SignTransaction(device.ID, "SALE_100_EUR", "")
+		assert.NoError(t, err)
+
+		entry, err := domain.ParseSignedData(result.SignedData)
+		assert.NoError(t, err)
+		assert.Equal(t, domain.SignedDataFormatV1, entry.Format)
+		assert.Equal(t, "SALE_100_EUR", entry.Data)
+	})
+
+	t.Run("legacy format for existing chains", func(t *testing.T) {
+		deviceService, _, device := newDevice(t, domain.SignedDataFormatLegacy)
+		assert.Equal(t, domain.SignedDataFormatLegacy, device.SignedDataFormat)
+
+		result, err := deviceService.SignTransaction(device.ID, "SALE_100_EUR", "")
+		assert.NoError(t, err)
+		assert.Equal(t, "0_SALE_100_EUR_"+base64.RawStdEncoding.EncodeToString([]byte(device.ID)), result.SignedData)
+
+		entry, err := domain.ParseSignedData(result.SignedData)
+		assert.NoError(t, err)
+		assert.Equal(t, domain.SignedDataFormatLegacy, entry.Format)
+		assert.Equal(t, 0, entry.Counter)
+		assert.Equal(t, "SALE_100_EUR", entry.Data)
+		assert.Nil(t, entry.Timestamp)
+	})
+
+	t.Run("V1 format round trip", func(t *testing.T) {
+		deviceService, _, device := newDevice(t, domain.SignedDataFormatV1)
+
+		first, err := deviceService.SignTransaction(device.ID, "SALE_100_EUR", "")
+		assert.NoError(t, err)
+		data := "4:2,\n" + strings.Repeat("_", 3)
+		second, err := deviceService.SignTransaction(device.ID, data, "")
+		assert.NoError(t, err)
+
+		assert.True(t, strings.HasPrefix(second.SignedData, "v1:1:1,36:"+device.ID+","))
+
+		entry, err := domain.ParseSignedData(second.SignedData)
+		assert.NoError(t, err)
+		assert.Equal(t, domain.SignedDataFormatV1, entry.Format)
+		assert.Equal(t, 1, entry.Counter)
+		assert.Equal(t, device.ID, entry.DeviceID)
+		assert.Equal(t, data, entry.Data, "data may contain separators")
+		assert.Equal(t, first.Signature, entry.PreviousSignature)
+		assert.NotNil(t, entry.Timestamp)
+		assert.Equal(t, second.SignedData, entry.Encode())
+
+		verification, err := deviceService.VerifySignature(device.ID, second.SignedData, second.Signature)
+		assert.NoError(t, err)
+		assert.True(t, verification.Valid)
+		assert.Equal(t, entry, verification.Entry)
+
+		report, err := deviceService.AuditDevice(device.ID)
+		assert.NoError(t, err)
+		assert.True(t, report.Valid)
+		assert.Equal(t, 2, report.TransactionsChecked)
+	})
+
+	t.Run("audit detects a rewritten timestamp", func(t *testing.T) {
+		deviceService, transactions, device := newDevice(t, domain.SignedDataFormatV1)
+
+		_, err := deviceService.SignTransaction(device.ID, "SALE", "")
+		assert.NoError(t, err)
+
+		stored, err := transactions.GetByCounter(device.ID, 0)
+		assert.NoError(t, err)
+		stored.CreatedAt = stored.CreatedAt.Add(-1)
+
+		report, err := deviceService.AuditDevice(device.ID)
+		assert.NoError(t, err)
+		assert.False(t, report.Valid)
+		assert.Equal(t, 0, report.BrokenLink.Counter)
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		deviceService := service.NewDeviceService(persistence.NewInMemoryRepository(), persistence.NewInMemoryTransactionRepository())
+
+		err := deviceService.CreateDevice(&domain.Device{ID: uuid.New().String(), Algorithm: "ECC", SignedDataFormat: "V2"})
+		assert.ErrorIs(t, err, domain.ErrInvalidSignedDataFormat)
+	})
+}
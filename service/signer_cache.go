@@ -0,0 +1,74 @@
+package service
+
+import (
+	"sync"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+// signerCache keeps the parsed signer of every device that has signed, so the private key
+// is not read, unwrapped and parsed again for every signature. An entry is only served
+// while the device still references the key it was built from.
+type signerCache struct {
+	mu      sync.RWMutex
+	signers map[string]cachedSigner
+}
+
+// signerSource identifies the key and settings a signer was built from.
+type signerSource struct {
+	keyHandle  string
+	privateKey string
+	options    crypto.SignatureOptions
+}
+
+type cachedSigner struct {
+	source signerSource
+	signer crypto.Signer
+}
+
+func newSignerCache() *signerCache {
+	return &signerCache{
+		mu:      sync.RWMutex{},
+		signers: make(map[string]cachedSigner),
+	}
+}
+
+func sourceOf(device *domain.Device) signerSource {
+	return signerSource{
+		keyHandle:  device.KeyHandle,
+		privateKey: device.PrivateKey,
+		options:    signatureOptions(device),
+	}
+}
+
+// get returns the cached signer of the device, or nil if there is none for its current key.
+func (c *signerCache) get(device *domain.Device) crypto.Signer {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cached, exists := c.signers[device.ID]
+	if !exists || cached.source != sourceOf(device) {
+		return nil
+	}
+
+	return cached.signer
+}
+
+func (c *signerCache) put(device *domain.Device, signer crypto.Signer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.signers[device.ID] = cachedSigner{
+		source: sourceOf(device),
+		signer: signer,
+	}
+}
+
+// invalidate drops the signer of a device whose key has been replaced or destroyed.
+func (c *signerCache) invalidate(deviceID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.signers, deviceID)
+}
@@ -0,0 +1,92 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingKeyStore counts how often signers are requested per key handle.
+type countingKeyStore struct {
+	crypto.KeyStore
+	requested map[string]int
+}
+
+func newCountingKeyStore() *countingKeyStore {
+	return &countingKeyStore{
+		KeyStore:  crypto.NewSoftwareKeyStore(),
+		requested: make(map[string]int),
+	}
+}
+
+func (ks *countingKeyStore) Signer(handle string, opts crypto.SignatureOptions) (crypto.Signer, error) {
+	ks.requested[handle]++
+	return ks.KeyStore.Signer(handle, opts)
+}
+
+func Test_deviceService_SignerCache(t *testing.T) {
+	t.Run("signer is loaded once per key", func(t *testing.T) {
+		keyStore := newCountingKeyStore()
+
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions, service.WithKeyStore(keyStore))
+
+		id := uuid.New().String()
+		device := &domain.Device{ID: id, Algorithm: "RSA"}
+		err := deviceService.CreateDevice(device)
+		assert.NoError(t, err, "should not fail to create device")
+		firstHandle := device.KeyHandle
+
+		for i := 0; i < 3; i++ {
+			_, err := deviceService.SignTransaction(id, "COFFEE", "")
+			assert.NoError(t, err, "should not fail to sign transaction")
+		}
+		assert.Equal(t, 1, keyStore.requested[firstHandle])
+
+		// rotation replaces the cached signer
+		rotated, err := deviceService.RotateKey(id)
+		assert.NoError(t, err, "should not fail to rotate key")
+
+		result, err := deviceService.SignTransaction(id, "COFFEE", "")
+		assert.NoError(t, err, "should sign with the new key")
+		assert.Equal(t, 1, keyStore.requested[rotated.KeyHandle])
+
+		verification, err := deviceService.VerifySignature(id, result.SignedData, result.Signature)
+		assert.NoError(t, err)
+		assert.True(t, verification.Valid, "cached signer should not outlive the rotation")
+	})
+
+	t.Run("cache can be disabled", func(t *testing.T) {
+		keyStore := newCountingKeyStore()
+
+		// spawn repository
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+
+		// spawn device service
+		deviceService := service.NewDeviceService(repository, transactions,
+			service.WithKeyStore(keyStore),
+			service.WithoutSignerCache(),
+		)
+
+		id := uuid.New().String()
+		device := &domain.Device{ID: id, Algorithm: "ECC"}
+		err := deviceService.CreateDevice(device)
+		assert.NoError(t, err, "should not fail to create device")
+
+		for i := 0; i < 3; i++ {
+			_, err := deviceService.SignTransaction(id, "COFFEE", "")
+			assert.NoError(t, err, "should not fail to sign transaction")
+		}
+		assert.Equal(t, 3, keyStore.requested[device.KeyHandle])
+	})
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/crypto"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+// SignStream signs content read as a stream, such as a file upload, without holding it in
+// memory. The content is hashed with hashAlgorithm, or the signature hash of the device if
+// none is given, and embedded into the device chain as
+// UPLOAD:<hash algorithm>:<hex digest>:<size in bytes>.
+func (s *deviceService) SignStream(deviceID string, hashAlgorithm string, content io.Reader, idempotencyKey string) (*domain.SignatureResult, error) {
+	if err := validateIdempotencyKey(idempotencyKey); err != nil {
+		return nil, err
+	}
+
+	// fail before reading what may be a large upload
+	device, err := s.repository.GetByID(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if device.CurrentStatus() != domain.DeviceStatusActive {
+		return nil, domain.ErrDeviceNotActive
+	}
+
+	if hashAlgorithm == "" {
+		hashAlgorithm = crypto.SignatureHash(device.Algorithm, signatureOptions(device))
+	}
+
+	data, err := buildUploadData(hashAlgorithm, content)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.sign(deviceID, data, idempotencyKey)
+}
+
+// buildUploadData hashes content and returns the chain entry data that records its digest
+// and size.
+func buildUploadData(hashAlgorithm string, content io.Reader) (string, error) {
+	h, ok := crypto.NewHash(hashAlgorithm)
+	if !ok {
+		return "", domain.ErrInvalidDigestAlgorithm
+	}
+
+	size, err := io.Copy(h, content)
+	if err != nil {
+		return "", domain.ErrUploadFailed
+	}
+
+	return fmt.Sprintf("%s:%s:%s:%d", domain.UploadPrefix, hashAlgorithm, hex.EncodeToString(h.Sum(nil)), size), nil
+}
@@ -0,0 +1,127 @@
+package service_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func Test_deviceService_SignStream(t *testing.T) {
+	content := bytes.Repeat([]byte{0x00, 0xff, 0x10}, 100000)
+
+	newDevice := func(t *testing.T, device *domain.Device) (service.DeviceService, persistence.TransactionRepository, string) {
+		transactions := persistence.NewInMemoryTransactionRepository()
+		deviceService := service.NewDeviceService(persistence.NewInMemoryRepository(), transactions)
+
+		device.ID = uuid.New().String()
+		err := deviceService.CreateDevice(device)
+		assert.NoError(t, err, "should not fail to create device")
+
+		return deviceService, transactions, device.ID
+	}
+
+	t.Run("content hash and size are embedded into the chain", func(t *testing.T) {
+		deviceService, transactions, id := newDevice(t, &domain.Device{Algorithm: "ECC", Curve: "P-256"})
+
+		result, err := deviceService.SignStream(id, "", bytes.NewReader(content), "")
+		assert.NoError(t, err)
+
+		sum := sha256.Sum256(content)
+		stored, err := transactions.GetByCounter(id, result.Counter)
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("UPLOAD:SHA-256:%s:%d", hex.EncodeToString(sum[:]), len(content)), stored.Data)
+
+		verification, err := deviceService.VerifySignature(id, result.SignedData, result.Signature)
+		assert.NoError(t, err)
+		assert.True(t, verification.Valid)
+
+		report, err := deviceService.AuditDevice(id)
+		assert.NoError(t, err)
+		assert.True(t, report.Valid)
+	})
+
+	t.Run("defaults to the signature hash of the device", func(t *testing.T) {
+		deviceService, transactions, id := newDevice(t, &domain.Device{Algorithm: "ECC", Curve: "P-521"})
+
+		result, err := deviceService.SignStream(id, "", bytes.NewReader(content), "")
+		assert.NoError(t, err)
+
+		sum := sha512.Sum512(content)
+		stored, err := transactions.GetByCounter(id, result.Counter)
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("UPLOAD:SHA-512:%s:%d", hex.EncodeToString(sum[:]), len(content)), stored.Data)
+	})
+
+	t.Run("explicit hash algorithm", func(t *testing.T) {
+		deviceService, transactions, id := newDevice(t, &domain.Device{Algorithm: "ED25519"})
+
+		result, err := deviceService.SignStream(id, "SHA-256", bytes.NewReader(nil), "")
+		assert.NoError(t, err)
+
+		sum := sha256.Sum256(nil)
+		stored, err := transactions.GetByCounter(id, result.Counter)
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("UPLOAD:SHA-256:%s:0", hex.EncodeToString(sum[:])), stored.Data)
+	})
+
+	t.Run("idempotent upload", func(t *testing.T) {
+		deviceService, _, id := newDevice(t, &domain.Device{Algorithm: "ECC"})
+
+		first, err := deviceService.SignStream(id, "", bytes.NewReader(content), "upload-1")
+		assert.NoError(t, err)
+
+		replayed, err := deviceService.SignStream(id, "", bytes.NewReader(content), "upload-1")
+		assert.NoError(t, err)
+		assert.Equal(t, first, replayed)
+
+		_, err = deviceService.SignStream(id, "", bytes.NewReader(content[1:]), "upload-1")
+		assert.Equal(t, domain.ErrIdempotencyKeyReused, err)
+	})
+
+	t.Run("upload data is reserved", func(t *testing.T) {
+		deviceService, _, id := newDevice(t, &domain.Device{Algorithm: "ECC"})
+
+		_, err := deviceService.SignTransaction(id, "UPLOAD:SHA-256:00:1", "")
+		assert.Equal(t, domain.ErrReservedData, err)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		deviceService, transactions, id := newDevice(t, &domain.Device{Algorithm: "ECC"})
+
+		_, err := deviceService.SignStream(id, "MD5", bytes.NewReader(content), "")
+		assert.Equal(t, domain.ErrInvalidDigestAlgorithm, err)
+
+		_, err = deviceService.SignStream(id, "", io.MultiReader(bytes.NewReader(content), failingReader{}), "")
+		assert.Equal(t, domain.ErrUploadFailed, err)
+
+		_, err = deviceService.SignStream(uuid.New().String(), "", bytes.NewReader(content), "")
+		assert.Equal(t, domain.ErrDeviceNotFound, err)
+
+		stored, err := transactions.ListByDevice(id)
+		assert.NoError(t, err)
+		assert.Empty(t, stored)
+
+		_, err = deviceService.ChangeDeviceStatus(id, domain.DeviceStatusDisabled, "")
+		assert.NoError(t, err)
+
+		_, err = deviceService.SignStream(id, "", failingReader{}, "")
+		assert.Equal(t, domain.ErrDeviceNotActive, err)
+	})
+}
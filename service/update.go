@@ -0,0 +1,84 @@
+package service
+
+import (
+	"unicode/utf8"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+)
+
+// UpdateDevice changes the label and metadata of a device. The signing state of the device
+// is not touched, so devices can be updated in any lifecycle state. Version counts the
+// revisions of label and metadata only: signatures, lifecycle changes and key rotations
+// leave it alone, so a conditional update of a device that is busy signing still applies.
+func (s *deviceService) UpdateDevice(deviceID string, update domain.DeviceUpdate) (*domain.Device, error) {
+	if update.Label == nil && len(update.Metadata) == 0 {
+		return nil, domain.ErrEmptyUpdate
+	}
+
+	if update.Label != nil {
+		if err := validateLabel(*update.Label); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.repository.Update(deviceID, func(device *domain.Device) error {
+		if update.IfVersion != nil && device.Version != *update.IfVersion {
+			return domain.ErrVersionMismatch
+		}
+
+		if update.Label != nil {
+			device.Label = *update.Label
+		}
+
+		if len(update.Metadata) > 0 {
+			// the map is shared with the stored device until the update is published
+			metadata := make(map[string]string, len(device.Metadata)+len(update.Metadata))
+			for key, value := range device.Metadata {
+				metadata[key] = value
+			}
+			for key, value := range update.Metadata {
+				if value == nil {
+					delete(metadata, key)
+					continue
+				}
+				metadata[key] = *value
+			}
+
+			if err := validateMetadata(metadata); err != nil {
+				return err
+			}
+
+			device.Metadata = metadata
+			if len(metadata) == 0 {
+				device.Metadata = nil
+			}
+		}
+
+		device.Version++
+		return nil
+	})
+}
+
+func validateLabel(label string) error {
+	if utf8.RuneCountInString(label) > domain.MaxLabelLength {
+		return domain.ErrInvalidLabel
+	}
+
+	return nil
+}
+
+func validateMetadata(metadata map[string]string) error {
+	if len(metadata) > domain.MaxMetadataEntries {
+		return domain.ErrInvalidMetadata
+	}
+
+	for key, value := range metadata {
+		keyLength := utf8.RuneCountInString(key)
+		if keyLength == 0 || keyLength > domain.MaxMetadataKeyLength ||
+			utf8.RuneCountInString(value) > domain.MaxMetadataValueLength {
+			return domain.ErrInvalidMetadata
+		}
+	}
+
+	return nil
+}
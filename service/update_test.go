@@ -0,0 +1,122 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/domain"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/persistence"
+	"github.com/fiskaly/coding-challenges/signing-service-challenge/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_deviceService_UpdateDevice(t *testing.T) {
+	newService := func(t *testing.T) (service.DeviceService, string) {
+		repository := persistence.NewInMemoryRepository()
+		transactions := persistence.NewInMemoryTransactionRepository()
+		deviceService := service.NewDeviceService(repository, transactions)
+
+		id := uuid.New().String()
+		device := &domain.Device{
+			ID:        id,
+			Algorithm: "ECC",
+			Label:     "Register 1",
+			Metadata:  map[string]string{"store": "berlin", "floor": "1"},
+		}
+		err := deviceService.CreateDevice(device)
+		assert.NoError(t, err, "should not fail to create device")
+		assert.Equal(t, 1, device.Version)
+
+		return deviceService, id
+	}
+
+	stringPtr := func(s string) *string { return &s }
+	intPtr := func(i int) *int { return &i }
+
+	t.Run("changes label and merges metadata", func(t *testing.T) {
+		deviceService, id := newService(t)
+
+		updated, err := deviceService.UpdateDevice(id, domain.DeviceUpdate{
+			Label: stringPtr("Register 2"),
+			Metadata: map[string]*string{
+				"floor": nil,
+				"till":  stringPtr("7"),
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "Register 2", updated.Label)
+		assert.Equal(t, map[string]string{"store": "berlin", "till": "7"}, updated.Metadata)
+		assert.Equal(t, 2, updated.Version)
+
+		stored, err := deviceService.GetDevice(id)
+		assert.NoError(t, err)
+		assert.Equal(t, updated.Metadata, stored.Metadata)
+	})
+
+	t.Run("signing leaves the version alone", func(t *testing.T) {
+		deviceService, id := newService(t)
+
+		_, err := deviceService.SignTransaction(id, "COFFEE", "")
+		assert.NoError(t, err)
+		_, err = deviceService.ChangeDeviceStatus(id, domain.DeviceStatusDisabled, "")
+		assert.NoError(t, err)
+
+		device, err := deviceService.GetDevice(id)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, device.Version)
+
+		updated, err := deviceService.UpdateDevice(id, domain.DeviceUpdate{Label: stringPtr("A"), IfVersion: intPtr(1)})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, updated.Version)
+	})
+
+	t.Run("rejects a stale version", func(t *testing.T) {
+		deviceService, id := newService(t)
+
+		_, err := deviceService.UpdateDevice(id, domain.DeviceUpdate{Label: stringPtr("A"), IfVersion: intPtr(1)})
+		assert.NoError(t, err)
+
+		_, err = deviceService.UpdateDevice(id, domain.DeviceUpdate{Label: stringPtr("B"), IfVersion: intPtr(1)})
+		assert.ErrorIs(t, err, domain.ErrVersionMismatch)
+
+		device, err := deviceService.GetDevice(id)
+		assert.NoError(t, err)
+		assert.Equal(t, "A", device.Label)
+		assert.Equal(t, 2, device.Version)
+	})
+
+	t.Run("validates label and metadata", func(t *testing.T) {
+		deviceService, id := newService(t)
+
+		_, err := deviceService.UpdateDevice(id, domain.DeviceUpdate{})
+		assert.ErrorIs(t, err, domain.ErrEmptyUpdate)
+
+		_, err = deviceService.UpdateDevice(id, domain.DeviceUpdate{Label: stringPtr(strings.Repeat("x", 256))})
+		assert.ErrorIs(t, err, domain.ErrInvalidLabel)
+
+		_, err = deviceService.UpdateDevice(id, domain.DeviceUpdate{Metadata: map[string]*string{"": stringPtr("x")}})
+		assert.ErrorIs(t, err, domain.ErrInvalidMetadata)
+
+		tooMany := make(map[string]*string)
+		for i := 0; i < domain.MaxMetadataEntries; i++ {
+			tooMany[uuid.New().String()] = stringPtr("x")
+		}
+		_, err = deviceService.UpdateDevice(id, domain.DeviceUpdate{Metadata: tooMany})
+		assert.ErrorIs(t, err, domain.ErrInvalidMetadata, "existing entries count towards the limit")
+
+		err = deviceService.CreateDevice(&domain.Device{
+			ID:        uuid.New().String(),
+			Algorithm: "ECC",
+			Metadata:  map[string]string{"store": strings.Repeat("x", 257)},
+		})
+		assert.ErrorIs(t, err, domain.ErrInvalidMetadata)
+	})
+
+	t.Run("device not found", func(t *testing.T) {
+		deviceService, _ := newService(t)
+
+		_, err := deviceService.UpdateDevice(uuid.New().String(), domain.DeviceUpdate{Label: stringPtr("A")})
+		assert.ErrorIs(t, err, domain.ErrDeviceNotFound)
+	})
+}